@@ -3,20 +3,43 @@ package main
 import (
 	"shogun/config"
 	"shogun/internal/api"
+	"shogun/internal/api/middleware/ratelimiter"
 	"shogun/internal/data"
 	"shogun/internal/model/chain"
+	"shogun/internal/security/accesstoken"
+	"shogun/internal/services/accountstore"
+	"shogun/internal/services/apikeystore"
+	"shogun/internal/services/auditstore"
+	"shogun/internal/services/autocomplete"
+	"shogun/internal/services/challengestore"
+	"shogun/internal/services/contactstore"
 	"shogun/internal/services/fileuploader"
 	"shogun/internal/services/historyfetch"
+	"shogun/internal/services/interactionstore"
 	"shogun/internal/services/natsclient"
+	"shogun/internal/services/pairingstore"
+	"shogun/internal/services/prefstore"
 	"shogun/internal/services/pricefetcher"
+	"shogun/internal/services/ratelimitstore"
+	"shogun/internal/services/rolestore"
+	"shogun/internal/services/screening"
+	"shogun/internal/services/sessionrevoke"
+	"shogun/internal/services/sessionstore"
 	"shogun/internal/services/siglocker"
+	"shogun/internal/services/signupguard"
+	"shogun/internal/services/suspension"
 	"shogun/internal/services/tokenstore"
 	"shogun/internal/services/usercache"
+	"shogun/internal/services/usereraser"
 	"shogun/internal/services/userinfosync"
 	"shogun/internal/services/userstore"
+	"shogun/internal/services/visibility"
 	"shogun/internal/services/walletstore"
+	"time"
 
 	"github.com/dreson4/graceful/v2"
+	"github.com/jmoiron/sqlx"
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/rs/zerolog/log"
 )
 
@@ -27,12 +50,43 @@ func init() {
 
 func main() {
 	log.Info().Msg("shogun is starting...")
+	accesstoken.Init()
 	db := data.Init(config.Cfg.PostgresURL)
-	nats, _ := natsclient.Init(config.Cfg.ServerID, config.Cfg.NatsUrl)
-	sigChecker := siglocker.NewHandler(nats)
-	userStore := userstore.NewSqlStore(db)
+	nats, js := natsclient.Init(config.Cfg.ServerID, config.Cfg.NatsUrl)
+	challengeTTL := time.Duration(config.Cfg.ChallengeTTLSeconds) * time.Second
+	sigChecker := newReplayStore(db, js, challengeTTL)
+	sessionStore := sessionstore.NewSqlStore(db)
+	recentlyRevoked, err := sessionStore.GetRevokedSince(time.Now().Add(-accesstoken.Duration()))
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load revoked sessions")
+	}
+	sessionLastSeen := sessionstore.NewLastSeen(sessionStore, time.Duration(config.Cfg.SessionLastSeenFlushSeconds)*time.Second)
+	sessionRevoker := sessionrevoke.NewHandler(nats, accesstoken.Duration(), recentlyRevoked)
+	challengeStore := challengestore.NewNats(js, challengeTTL)
+	userStore := userstore.NewSqlStore(db, time.Duration(config.Cfg.UsernameQuarantineDays)*24*time.Hour)
 	userInfoSync := userinfosync.NewNats(nats)
 	userCache := usercache.NewLruCache(userStore, userInfoSync)
+	userCache.Init()
+	contactStore := contactstore.NewSqlStore(db)
+	visibilityPolicy := visibility.New(prefstore.NewSqlStore(db), contactStore)
+	userAutocomplete := autocomplete.NewTrieAutocomplete(userStore, userCache, userInfoSync, visibilityPolicy)
+	if err = userAutocomplete.Run(); err != nil {
+		log.Fatal().Err(err).Msg("failed to start username autocomplete")
+	}
+	suspendedUsers, err := userStore.GetSuspendedIDs()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load suspended users")
+	}
+	suspensionChecker := suspension.NewHandler(nats, suspendedUsers)
+	userEraser := usereraser.New(
+		userStore,
+		accountstore.NewSqlStore(db),
+		prefstore.NewSqlStore(db),
+		fileuploader.NewUploaderService(),
+		sessionStore,
+		sessionRevoker,
+		userInfoSync)
+	userEraser.Start(time.Duration(config.Cfg.AccountDeletionPurgeMinutes) * time.Minute)
 
 	storage := tokenstore.Init(
 		db,
@@ -42,23 +96,53 @@ func main() {
 		},
 		fileuploader.NewUploaderService())
 
+	screener := newScreener()
 	historyFetcher := historyfetch.NewAllChainFetcher(
 		map[chain.Chain]historyfetch.ChainFetcher{
 			chain.Solana: historyfetch.NewSolanaHeliusFetcher(config.Cfg.SolanaHeliusApiKey, storage, userCache),
 			chain.Sui:    historyfetch.NewSuiFetcher(config.Cfg.SuiRPC, storage, userCache),
-		})
+		},
+		screener,
+		userCache,
+		interactionstore.NewSqlStore(db),
+		visibilityPolicy)
 
 	walletstore.Init(storage)
 	pricefetcher.StartAll()
+	rateLimitStore := newRateLimitStore(db, js)
+	apiKeyStore := apikeystore.NewSqlStore(db)
+	signupGuard := signupguard.New(rateLimitStore,
+		config.Cfg.SignupPerIPHourly,
+		config.Cfg.SignupPerSubnetHourly,
+		config.Cfg.SignupPowDifficulty)
 
 	params := &api.ConfigParams{
 		DB:             db,
 		Mode:           config.Cfg.Mode,
 		SigChecker:     sigChecker,
+		SessionStore:   sessionStore,
+		SessionToucher: sessionLastSeen,
+		SessionRevoker: sessionRevoker,
+		ChallengeStore: challengeStore,
 		TokenStore:     storage,
 		UserStore:      userStore,
 		UserCache:      userCache,
+		Autocomplete:   userAutocomplete,
+		UserInfoSync:   userInfoSync,
+		UserEraser:     userEraser,
+		RateLimiter:    ratelimiter.New(rateLimitStore),
+		RateLimitStore: rateLimitStore,
+		RoleStore:      rolestore.NewSqlStore(db),
+		AuditStore:     auditstore.NewSqlStore(db),
+		ApiKeyStore:    apiKeyStore,
+		PairingStore:   pairingstore.NewNats(js, time.Duration(config.Cfg.PairingTTLSeconds)*time.Second),
+		SignupGuard:    signupGuard,
+		Screener:       screener,
+		ApiKeyUsage:    apikeystore.NewUsageCounter(apiKeyStore, time.Duration(config.Cfg.ApiKeyUsageFlushSeconds)*time.Second),
+		Suspension:     suspensionChecker,
 		HistoryFetcher: historyFetcher,
+		Visibility:     visibilityPolicy,
+		ContactStore:   contactStore,
 	}
 	apiServer := api.Init(params)
 	go func() {
@@ -69,3 +153,49 @@ func main() {
 	graceful.Initialize()
 	graceful.Wait()
 }
+
+// newReplayStore - signatures are kept as long as the challenge they answer could still be valid
+func newReplayStore(db *sqlx.DB, js jetstream.JetStream, ttl time.Duration) siglocker.UseChecker {
+	switch config.Cfg.ReplayStore {
+	case "nats":
+		return siglocker.NewNats(js, ttl)
+	case "postgres":
+		return siglocker.NewSqlStore(db, ttl)
+	case "memory":
+		if config.Cfg.IsRelease() {
+			log.Warn().Msg("memory replay store only protects a single server")
+		}
+		return siglocker.NewMemory(ttl)
+	}
+	log.Fatal().Str("replay_store", config.Cfg.ReplayStore).Msg("unknown replay store")
+	return nil
+}
+
+func newRateLimitStore(db *sqlx.DB, js jetstream.JetStream) ratelimitstore.Store {
+	switch config.Cfg.RateLimitStore {
+	case "nats":
+		return ratelimitstore.NewNats(js)
+	case "postgres":
+		return ratelimitstore.NewSqlStore(db)
+	case "memory":
+		if config.Cfg.IsRelease() {
+			log.Warn().Msg("memory rate limit store counts per server")
+		}
+		return ratelimitstore.NewMemory()
+	}
+	log.Fatal().Str("rate_limit_store", config.Cfg.RateLimitStore).Msg("unknown rate limit store")
+	return nil
+}
+
+func newScreener() screening.Screener {
+	if config.Cfg.RiskListsDir == "" {
+		log.Warn().Msg("risk_lists_dir not set, addresses are not screened")
+		return screening.None{}
+	}
+	lists, err := screening.NewFileLists(config.Cfg.RiskListsDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load risk lists")
+	}
+	lists.Start(time.Duration(config.Cfg.RiskListsReloadSeconds) * time.Second)
+	return lists
+}
@@ -1,6 +1,11 @@
 package config
 
-import "github.com/ilyakaznacheev/cleanenv"
+import (
+	"slices"
+	"strings"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
 
 type Mode string
 
@@ -17,16 +22,57 @@ type Config struct {
 	PostgresURL       string `env:"postgres_url"`
 	Port              string `env:"port"`
 	Mode              Mode   `env:"mode"`
-	AccessTokenSecret string `env:"access_token_secret"`
 	R2AccessKeyID     string `env:"r2_access_key_id"`
 	R2SecretAccessKey string `env:"r2_secret_access_key"`
 	R2AccountID       string `env:"r2_account_id"`
 
+	AccessTokenKeysDir   string `env:"access_token_keys_dir"`
+	AccessTokenActiveKey string `env:"access_token_active_key"`
+	AccessTokenMinutes   int    `env:"access_token_minutes" env-default:"15"`
+	RefreshTokenDays     int    `env:"refresh_token_days" env-default:"60"`
+
+	SessionLastSeenFlushSeconds int `env:"session_last_seen_flush_seconds" env-default:"60"`
+
+	AuthDomain          string `env:"auth_domain" env-default:"shogun.social"`
+	AuthURI             string `env:"auth_uri" env-default:"https://shogun.social"`
+	ChallengeTTLSeconds int    `env:"challenge_ttl_seconds" env-default:"300"`
+	PairingTTLSeconds   int    `env:"pairing_ttl_seconds" env-default:"120"`
+	PairingWaitSeconds  int    `env:"pairing_wait_seconds" env-default:"25"`
+	ReplayStore         string `env:"replay_store" env-default:"nats"`     //nats, postgres or memory
+	RateLimitStore      string `env:"rate_limit_store" env-default:"nats"` //nats, postgres or memory
+
+	WebSessionsEnabled bool     `env:"web_sessions_enabled" env-default:"false"`
+	CookieDomain       string   `env:"cookie_domain"`
+	CookieSecure       bool     `env:"cookie_secure" env-default:"true"`
+	CookieSameSite     string   `env:"cookie_same_site" env-default:"lax"` //lax, strict or none
+	CORSAllowOrigins   []string `env:"cors_allow_origins" env-separator:","`
+	CORSMaxAgeSeconds  int      `env:"cors_max_age_seconds" env-default:"600"`
+	TrustedProxies     []string `env:"trusted_proxies" env-separator:","` //cidrs or ips of the load balancers, X-Forwarded-For is ignored without them
+
+	SignupPerIPHourly     int64 `env:"signup_per_ip_hourly" env-default:"5"`      //0 turns it off
+	SignupPerSubnetHourly int64 `env:"signup_per_subnet_hourly" env-default:"30"` //0 turns it off
+	SignupPowDifficulty   int   `env:"signup_pow_difficulty" env-default:"0"`     //leading zero bits, 0 turns it off
+
+	SignedRequestsEnforced     bool  `env:"signed_requests_enforced" env-default:"false"`
+	SignedRequestMaxAgeSeconds int   `env:"signed_request_max_age_seconds" env-default:"60"`
+	SignedRequestMaxBodyBytes  int64 `env:"signed_request_max_body_bytes" env-default:"2097152"`
+
+	AccountDeletionGraceDays    int `env:"account_deletion_grace_days" env-default:"14"` //0 erases right away
+	AccountDeletionPurgeMinutes int `env:"account_deletion_purge_minutes" env-default:"10"`
+
+	ApiKeyMaxPerUser        int   `env:"api_key_max_per_user" env-default:"10"`
+	ApiKeyHourlyQuota       int64 `env:"api_key_hourly_quota" env-default:"1000"`
+	ApiKeyUsageFlushSeconds int   `env:"api_key_usage_flush_seconds" env-default:"60"`
+
+	RiskListsDir           string `env:"risk_lists_dir"` //csv lists, screening is off without it
+	RiskListsReloadSeconds int    `env:"risk_lists_reload_seconds" env-default:"30"`
+
 	SuiRPC             string `env:"sui_rpc"`
 	SolanaRPC          string `env:"solana_rpc"`
 	SolanaHeliusApiKey string `env:"solana_helius_api_key"`
 
 	UsernameUpdateLockDays   int `env:"username_update_lock_days" env-default:"7"`
+	UsernameQuarantineDays   int `env:"username_quarantine_days" env-default:"30"` //only the previous owner can take a released username
 	NameUpdateLockDays       int `env:"name_update_lock_days" env-default:"1"`
 	UsernameMaxLength        int `env:"username_max_length" env-default:"18"`
 	UsernameMinLengthNormal  int `env:"username_min_length_normal" env-default:"6"`
@@ -44,8 +90,17 @@ func Init() *Config {
 	if err != nil {
 		panic(err)
 	}
-	if Cfg.AccessTokenSecret == "" {
-		panic("access_token_secret missing")
+	if Cfg.IsRelease() && (Cfg.AccessTokenKeysDir == "" || Cfg.AccessTokenActiveKey == "") {
+		panic("access_token_keys_dir and access_token_active_key are required in release mode")
+	}
+	if Cfg.WebSessionsEnabled {
+		//browsers refuse credentials for a wildcard origin and SameSite=None cookies that aren't secure
+		if slices.Contains(Cfg.CORSAllowOrigins, "*") {
+			panic("cors_allow_origins can't be * with web sessions enabled")
+		}
+		if strings.EqualFold(Cfg.CookieSameSite, "none") && !Cfg.CookieSecure {
+			panic("cookie_same_site none needs cookie_secure")
+		}
 	}
 	return &Cfg
 }
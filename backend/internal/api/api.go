@@ -5,31 +5,95 @@ import (
 	"shogun/config"
 	"shogun/internal/api/middleware/auth"
 	"shogun/internal/api/middleware/ratelimiter"
+	"shogun/internal/api/middleware/realip"
+	"shogun/internal/api/middleware/signedrequest"
 	"shogun/internal/api/middleware/simplelog"
 	v1 "shogun/internal/api/v1"
+	"shogun/internal/model/apikey"
+	"shogun/internal/model/role"
 	"shogun/internal/services/accountstore"
+	"shogun/internal/services/apikeystore"
+	"shogun/internal/services/auditstore"
+	"shogun/internal/services/autocomplete"
+	"shogun/internal/services/challengestore"
+	"shogun/internal/services/contactstore"
 	"shogun/internal/services/fileuploader"
 	"shogun/internal/services/historyfetch"
+	"shogun/internal/services/pairingstore"
 	"shogun/internal/services/prefstore"
 	"shogun/internal/services/pricefetcher"
+	"shogun/internal/services/ratelimitstore"
+	"shogun/internal/services/rolestore"
+	"shogun/internal/services/screening"
+	"shogun/internal/services/sessionrevoke"
+	"shogun/internal/services/sessionstore"
 	"shogun/internal/services/siglocker"
+	"shogun/internal/services/signupguard"
+	"shogun/internal/services/suspension"
 	"shogun/internal/services/tokenstore"
 	"shogun/internal/services/usercache"
+	"shogun/internal/services/usereraser"
+	"shogun/internal/services/userinfosync"
 	"shogun/internal/services/userstore"
+	"shogun/internal/services/visibility"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 )
 
 type ConfigParams struct {
 	DB             *sqlx.DB
 	Mode           config.Mode
 	SigChecker     siglocker.UseChecker
+	SessionStore   sessionstore.Store
+	SessionToucher sessionstore.Toucher
+	SessionRevoker sessionrevoke.Checker
+	ChallengeStore challengestore.Store
 	TokenStore     tokenstore.Store
 	UserStore      userstore.Store
 	UserCache      usercache.SimpleCache
+	Autocomplete   autocomplete.Autocomplete
+	UserInfoSync   userinfosync.Service
+	UserEraser     *usereraser.Eraser
+	RateLimiter    *ratelimiter.Limiter
+	RoleStore      rolestore.Store
+	Suspension     suspension.Checker
+	AuditStore     auditstore.Store
+	ApiKeyStore    apikeystore.Store
+	ApiKeyUsage    *apikeystore.UsageCounter
+	PairingStore   pairingstore.Store
+	SignupGuard    *signupguard.Guard
+	Screener       screening.Screener
+	RateLimitStore ratelimitstore.Store
 	HistoryFetcher historyfetch.AllFetcher
+	Visibility     *visibility.Policy
+	ContactStore   contactstore.Store
+}
+
+// cors - lets the configured web origins call the api, with cookies when web sessions are on
+func cors() echo.MiddlewareFunc {
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: config.Cfg.CORSAllowOrigins,
+		AllowMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete},
+		AllowHeaders: []string{
+			echo.HeaderContentType,
+			"Access-Token",
+			auth.CSRFHeader,
+			auth.SessionModeHeader,
+			auth.ApiKeyHeader,
+			signedrequest.HeaderSignature,
+			signedrequest.HeaderAddress,
+			signedrequest.HeaderChain,
+			signedrequest.HeaderTimestamp,
+			signedrequest.HeaderNonce,
+		},
+		ExposeHeaders:    []string{"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
+		AllowCredentials: config.Cfg.WebSessionsEnabled,
+		MaxAge:           config.Cfg.CORSMaxAgeSeconds,
+	})
 }
 
 type CustomValidator struct {
@@ -54,12 +118,24 @@ func Init(conf *ConfigParams) *echo.Echo {
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
-	e.Use(ratelimiter.InitEchoLimiter())
+	//sign up and rate limits key on the client ip, it can't come from a header the client sets
+	ipExtractor, err := realip.Extractor(config.Cfg.TrustedProxies)
+	if err != nil {
+		panic(err)
+	}
+	e.IPExtractor = ipExtractor
+	if len(config.Cfg.CORSAllowOrigins) > 0 {
+		e.Use(cors())
+	}
+	e.Use(conf.RateLimiter.Global())
 	e.Any("/ping", func(c echo.Context) error {
 		return c.String(http.StatusOK, "pong")
 	})
 	e.Use(simplelog.Logger)
+	auth.Init(conf.SessionRevoker, conf.SessionToucher, conf.Suspension)
+	auth.InitApiKeys(conf.ApiKeyStore, conf.RateLimitStore, conf.ApiKeyUsage)
 	e.Validator = &CustomValidator{validator: validator.New()}
+	e.GET("/.well-known/jwks.json", v1.NewSystemController().JWKSGET)
 
 	group := e.Group("/v1")
 	apiV1(group, conf)
@@ -72,6 +148,13 @@ func apiV1(e *echo.Group, conf *ConfigParams) {
 	fileUploadService := fileuploader.NewUploaderService()
 	preferenceService := prefstore.NewSqlStore(conf.DB)
 
+	// Rate limit policies, routes sharing a policy share its budget
+	loginLimit := conf.RateLimiter.Middleware(ratelimiter.Login)
+	searchLimit := conf.RateLimiter.Middleware(ratelimiter.Search)
+	assetsLimit := conf.RateLimiter.Middleware(ratelimiter.Assets)
+	uploadLimit := conf.RateLimiter.Middleware(ratelimiter.Upload)
+	contactRequestLimit := conf.RateLimiter.Middleware(ratelimiter.ContactRequest)
+
 	systemController := v1.NewSystemController()
 	e.GET("/system", systemController.SystemGET)
 	// Auth routes
@@ -80,12 +163,31 @@ func apiV1(e *echo.Group, conf *ConfigParams) {
 		accountService,
 		conf.UserStore,
 		preferenceService,
+		conf.SessionStore,
+		conf.ChallengeStore,
 		conf.SigChecker,
+		conf.SessionRevoker,
+		conf.RoleStore,
+		conf.Suspension,
+		conf.UserInfoSync,
+		conf.AuditStore,
+		conf.PairingStore,
+		conf.SignupGuard,
 	)
 
-	e.GET("/auth/login", authController.LoginGET)
-	e.GET("/auth/exists/:address", authController.Exists)
-	e.POST("/auth/link", authController.LinkAccounts)
+	e.GET("/auth/challenge", authController.ChallengeGET, loginLimit)
+	e.GET("/auth/login", authController.LoginGET, loginLimit)
+	e.GET("/auth/exists/:address", authController.Exists, loginLimit)
+	e.POST("/auth/link", authController.LinkAccounts, auth.Auth)
+	e.POST("/auth/unlink", authController.UnlinkAccount, auth.Auth)
+	e.POST("/auth/primary", authController.SetPrimaryAccount, auth.Auth)
+	e.POST("/auth/refresh", authController.Refresh, loginLimit)
+	e.POST("/auth/logout", authController.Logout, auth.Auth)
+	e.POST("/auth/pair", authController.StartPairing, loginLimit)
+	e.GET("/auth/pair/:code", authController.GetPairing, auth.Auth)
+	e.POST("/auth/pair/:code/reject", authController.RejectPairing, auth.Auth)
+	e.GET("/auth/pair/:code/wait", authController.WaitPairing, loginLimit)
+	e.GET("/auth/pair/:code/ws", authController.PairingSocket, loginLimit)
 
 	// User routes
 	userController := v1.NewUserController(
@@ -94,22 +196,110 @@ func apiV1(e *echo.Group, conf *ConfigParams) {
 		fileUploadService,
 		preferenceService,
 		conf.UserCache,
+		conf.UserEraser,
+		conf.AuditStore,
+		conf.UserInfoSync,
+		conf.Autocomplete,
+		conf.Visibility,
 	)
-	e.GET("/user/profile", userController.GetPublicProfile)
+	e.GET("/user/profile", userController.GetPublicProfile, searchLimit)
 	e.GET("/user/me", userController.GetMe, auth.Auth)
-	e.POST("/user/update", userController.Update, auth.Auth)
-	e.POST("/user/thumbnail", userController.UpdateThumbnail, auth.Auth)
 	e.GET("/user/preferences", userController.GetPreferences, auth.Auth)
-	e.POST("/user/preferences", userController.UpdatePreferences, auth.Auth)
-	e.GET("/user/search/addresses", userController.GetManyAddresses, auth.Auth)
-	e.GET("/user/search/username/:username", userController.GetUsername, auth.Auth)
+	e.GET("/user/security-log", userController.SecurityLog, auth.Auth)
+	e.GET("/user/username-history", userController.UsernameHistory, auth.Auth)
+	e.GET("/user/search", userController.Search, auth.AuthOrKey(apikey.ScopeUserResolve), searchLimit)
+	e.GET("/user/search/addresses", userController.GetManyAddresses, auth.AuthOrKey(apikey.ScopeUserResolve), searchLimit)
+	e.GET("/user/search/username/:username", userController.GetUsername, auth.AuthOrKey(apikey.ScopeUserResolve), searchLimit)
+	e.GET("/user/search/autocomplete", userController.Autocomplete, auth.AuthOrKey(apikey.ScopeUserResolve), searchLimit)
+	e.GET("/user/:id/attestation", userController.GetAttestation, searchLimit)
+
+	// Signed user routes, they need a signature by a linked key on top of the access token
+	signedConf := signedrequest.Config{
+		Accounts:     accountService,
+		Challenges:   conf.ChallengeStore,
+		Replay:       conf.SigChecker,
+		MaxAge:       time.Duration(config.Cfg.SignedRequestMaxAgeSeconds) * time.Second,
+		MaxBodyBytes: config.Cfg.SignedRequestMaxBodyBytes,
+		Optional:     !config.Cfg.SignedRequestsEnforced,
+	}
+	signed := e.Group("/user", auth.Auth, signedrequest.New(signedConf))
+	signed.POST("/update", userController.Update)
+	signed.POST("/thumbnail", userController.UpdateThumbnail, uploadLimit)
+	signed.POST("/preferences", userController.UpdatePreferences)
+
+	// API key routes, keys themselves can't manage keys
+	apiKeyController := v1.NewApiKeyController(conf.ApiKeyStore, conf.UserStore, conf.AuditStore)
+	e.GET("/user/api-keys", apiKeyController.List, auth.Auth)
+	signed.POST("/api-keys", apiKeyController.Create)
+	e.DELETE("/user/api-keys/:id", apiKeyController.Revoke, auth.Auth)
+	e.GET("/user/api-keys/:id/usage", apiKeyController.Usage, auth.Auth)
+
+	//deleting can't be undone by an attacker holding just a token, always signed by the login key
+	primarySignedConf := signedConf
+	primarySignedConf.PrimaryOnly = true
+	primarySignedConf.Optional = false
+	e.DELETE("/user/me", userController.Delete, auth.Auth, signedrequest.New(primarySignedConf))
+
+	//approving a pairing hands out a full session, so it's always signed
+	pairingSignedConf := signedConf
+	pairingSignedConf.Optional = false
+	e.POST("/auth/pair/:code/approve", authController.ApprovePairing, auth.Auth, signedrequest.New(pairingSignedConf))
+
+	sessionController := v1.NewSessionController(conf.SessionStore, conf.SessionRevoker, conf.AuditStore)
+	e.GET("/user/sessions", sessionController.List, auth.Auth)
+	e.DELETE("/user/sessions", sessionController.RevokeAll, auth.Auth)
+	e.DELETE("/user/sessions/:id", sessionController.Revoke, auth.Auth)
+
+	// Contact routes
+	contactController := v1.NewContactController(conf.ContactStore, conf.UserCache)
+	e.GET("/contacts", contactController.List, auth.Auth)
+	e.GET("/contacts/requests", contactController.Requests, auth.Auth)
+	e.POST("/contacts/:id/request", contactController.Request, auth.Auth, contactRequestLimit)
+	e.POST("/contacts/:id/accept", contactController.Accept, auth.Auth)
+	e.POST("/contacts/:id/decline", contactController.Decline, auth.Auth)
+	e.DELETE("/contacts/:id", contactController.Remove, auth.Auth)
+	e.GET("/contacts/:id/mutual", contactController.Mutual, auth.Auth)
 
 	// Wallet routes
-	walletController := v1.NewWalletController(conf.HistoryFetcher)
-	e.GET("/wallet/assets", walletController.FetchAssets, auth.Auth)
-	e.GET("/wallet/history", walletController.FetchHistory, auth.Auth)
+	walletController := v1.NewWalletController(conf.HistoryFetcher, conf.Screener)
+	e.GET("/wallet/assets", walletController.FetchAssets, auth.Auth, assetsLimit)
+	e.GET("/wallet/history", walletController.FetchHistory, auth.Auth, assetsLimit)
+
+	addressController := v1.NewAddressController(conf.Screener)
+	e.GET("/address/screen", addressController.Screen, auth.Auth, searchLimit)
 
 	tokenController := v1.NewTokenController(conf.TokenStore, pricefetcher.G())
-	e.GET("/token/info/:address", tokenController.TokenInfoGET, auth.Auth)
-	e.GET("/token/price/:address", tokenController.TokenPriceGET, auth.Auth)
+	e.GET("/token/info/:address", tokenController.TokenInfoGET, auth.AuthOrKey(apikey.ScopeTokenRead), assetsLimit)
+	e.GET("/token/price/:address", tokenController.TokenPriceGET, auth.AuthOrKey(apikey.ScopeTokenRead), assetsLimit)
+
+	// Admin routes, every route needs a permission of the staff role
+	adminController := v1.NewAdminController(
+		conf.UserStore,
+		accountService,
+		conf.RoleStore,
+		conf.TokenStore,
+		conf.Suspension,
+		conf.UserInfoSync,
+		conf.AuditStore,
+		conf.SignupGuard,
+	)
+	admin := e.Group("/admin", auth.Auth)
+	can := func(p role.Permission) echo.MiddlewareFunc {
+		return auth.RequirePermission(conf.RoleStore, p)
+	}
+	admin.GET("/users", adminController.FindUser, can(role.PermissionUsersRead))
+	admin.POST("/users/:id/suspend", adminController.Suspend, can(role.PermissionUsersSuspend))
+	admin.POST("/users/:id/unsuspend", adminController.Unsuspend, can(role.PermissionUsersSuspend))
+	admin.POST("/users/:id/username", adminController.Rename, can(role.PermissionUsersRename))
+	admin.POST("/users/:id/username/unlock", adminController.ResetUsernameLock, can(role.PermissionUsersRename))
+	admin.POST("/users/:id/role", adminController.SetRole, can(role.PermissionRolesManage))
+	admin.GET("/reserved-usernames", adminController.ReservedWords, can(role.PermissionUsersRead))
+	admin.POST("/reserved-usernames", adminController.AddReservedWord, can(role.PermissionUsernamesReserve))
+	admin.DELETE("/reserved-usernames/:word", adminController.RemoveReservedWord, can(role.PermissionUsernamesReserve))
+	admin.POST("/tokens/:address", adminController.OverrideToken, can(role.PermissionTokensEdit))
+	admin.GET("/signups", adminController.SignupMetrics, can(role.PermissionUsersRead))
+	admin.GET("/audit", adminController.QueryAudit, can(role.PermissionAuditRead))
+	admin.GET("/users/:id/api-keys", apiKeyController.AdminList, can(role.PermissionApiKeysEdit))
+	admin.POST("/users/:id/api-keys", apiKeyController.AdminCreate, can(role.PermissionApiKeysEdit))
+	admin.DELETE("/api-keys/:id", apiKeyController.AdminRevoke, can(role.PermissionApiKeysEdit))
 }
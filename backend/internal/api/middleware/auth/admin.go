@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"shogun/internal/api/response"
+	"shogun/internal/model/role"
+	"shogun/internal/services/rolestore"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequirePermission - runs after Auth, the role claim of the token has to allow p,
+// the role table is checked too so a removed role stops working before the token expires
+func RequirePermission(roles rolestore.Store, p role.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(e echo.Context) error {
+			if !GetRole(e).Can(p) {
+				return response.ForbiddenError(e)
+			}
+			current, err := roles.Get(MustGetUserID(e))
+			if err != nil {
+				return response.ServerError(e, err, "")
+			}
+			if !current.Can(p) {
+				return response.ForbiddenError(e)
+			}
+			return next(e)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"shogun/internal/api/response"
+	"shogun/internal/model/apikey"
+	"shogun/internal/services/apikeystore"
+	"shogun/internal/services/ratelimitstore"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const ApiKeyHeader = "X-Api-Key"
+
+// quotaWindow - key quotas are per hour, the longest window the shared counters keep
+const quotaWindow = ratelimitstore.LongestWindow
+
+var apiKeys apikeystore.Store
+var apiKeyQuotas ratelimitstore.Store
+var apiKeyUsage *apikeystore.UsageCounter
+
+// InitApiKeys - sets the stores used to authenticate api keys, count their quota and usage
+func InitApiKeys(keys apikeystore.Store, quotas ratelimitstore.Store, usage *apikeystore.UsageCounter) {
+	apiKeys = keys
+	apiKeyQuotas = quotas
+	apiKeyUsage = usage
+}
+
+// AuthOrKey - like Auth, but servers of integrations can send an api key with the scope instead of an access token,
+// the key acts as its owner so handlers read the user id the same way
+func AuthOrKey(scope apikey.Scope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withToken := Auth(next)
+		return func(e echo.Context) error {
+			secret := e.Request().Header.Get(ApiKeyHeader)
+			if secret == "" || apiKeys == nil {
+				return withToken(e)
+			}
+			k, err := lookupApiKey(e, secret)
+			if err != nil {
+				if errors.Is(err, apikeystore.ErrorKeyNotFound) {
+					return response.OtherErrors(e, response.ErrorApiKeyInvalid, "api key invalid")
+				}
+				return response.ServerError(e, err, "")
+			}
+			if k.IsRevoked() {
+				return response.OtherErrors(e, response.ErrorApiKeyInvalid, "api key revoked")
+			}
+			if !k.Scopes.Has(scope) {
+				return response.OtherErrors(e, response.StatusForbidden, "api key is missing scope "+string(scope))
+			}
+			if suspended != nil && suspended.IsSuspended(k.UserID) {
+				return response.OtherErrors(e, response.ErrorUserSuspended, "user suspended")
+			}
+			if over := checkQuota(e, k); over {
+				return e.JSON(http.StatusTooManyRequests, nil)
+			}
+			if apiKeyUsage != nil {
+				apiKeyUsage.Count(k.ID)
+			}
+			e.Set("access-token-userid", k.UserID)
+			e.Set("api-key-id", k.ID)
+			return next(e)
+		}
+	}
+}
+
+// lookupApiKey - the key of the secret, kept on the context so PeekApiKeyID and AuthOrKey
+// look it up once per request
+func lookupApiKey(e echo.Context, secret string) (*apikey.Key, error) {
+	if k, ok := e.Get("api-key").(*apikey.Key); ok {
+		return k, nil
+	}
+	k, err := apiKeys.GetBySecretHash(apikey.HashSecret(secret))
+	if err != nil {
+		return nil, err
+	}
+	e.Set("api-key", k)
+	return k, nil
+}
+
+// PeekApiKeyID - the key sent with the request before any route checked it, for middlewares that run
+// before AuthOrKey, nothing is enforced so revoked keys and keys without the scope are returned too
+func PeekApiKeyID(e echo.Context) (int64, bool) {
+	secret := e.Request().Header.Get(ApiKeyHeader)
+	if secret == "" || apiKeys == nil {
+		return 0, false
+	}
+	k, err := lookupApiKey(e, secret)
+	if err != nil {
+		return 0, false
+	}
+	return k.ID, true
+}
+
+// checkQuota - counts the request against the hourly quota of the key and sets the quota headers
+func checkQuota(e echo.Context, k *apikey.Key) bool {
+	if apiKeyQuotas == nil || k.HourQuota <= 0 {
+		return false
+	}
+	window := int64(quotaWindow / time.Second)
+	now := time.Now().Unix()
+	windowStart := now - now%window
+	count, err := apiKeyQuotas.Increment(fmt.Sprintf("apikey.%d.%d", k.ID, windowStart), quotaWindow)
+	if err != nil {
+		//same as the rate limiter, a broken counter store shouldn't lock partners out
+		log.Err(err).Int64("key", k.ID).Msg("api key quota check failed")
+		return false
+	}
+	reset := windowStart + window - now
+	h := e.Response().Header()
+	h.Set("X-Api-Key-Quota", strconv.FormatInt(k.HourQuota, 10))
+	h.Set("X-Api-Key-Quota-Remaining", strconv.FormatInt(max(k.HourQuota-count, 0), 10))
+	h.Set("X-Api-Key-Quota-Reset", strconv.FormatInt(reset, 10))
+	if count > k.HourQuota {
+		h.Set("Retry-After", strconv.FormatInt(reset, 10))
+		return true
+	}
+	return false
+}
+
+// GetApiKeyID - the key the request was made with, false for access tokens
+func GetApiKeyID(e echo.Context) (int64, bool) {
+	id, ok := e.Get("api-key-id").(int64)
+	return id, ok
+}
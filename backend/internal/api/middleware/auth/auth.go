@@ -3,34 +3,104 @@ package auth
 import (
 	"errors"
 	"shogun/internal/api/response"
+	"shogun/internal/model/role"
 	"shogun/internal/security/accesstoken"
+	"shogun/internal/services/sessionrevoke"
+	"shogun/internal/services/sessionstore"
+	"shogun/internal/services/suspension"
 
 	"github.com/labstack/echo/v4"
 )
 
+var revoked sessionrevoke.Checker
+var lastSeen sessionstore.Toucher
+var suspended suspension.Checker
+
+// Init - sets the checkers used to reject access tokens of revoked sessions
+// and suspended users, and the toucher that keeps the sessions last seen time
+func Init(checker sessionrevoke.Checker, toucher sessionstore.Toucher, suspensionChecker suspension.Checker) {
+	revoked = checker
+	lastSeen = toucher
+	suspended = suspensionChecker
+}
+
 func Auth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(e echo.Context) error {
 		t := e.Request().Header.Get("Access-Token")
 		if t == "" {
-			return response.UnauthorizedError(e)
+			//browser sessions send the token as a cookie, which any site can make the browser send
+			t = cookieValue(e, AccessTokenCookie)
+			if t == "" {
+				return response.UnauthorizedError(e)
+			}
+			if !isSafeMethod(e.Request().Method) && !ValidCSRF(e) {
+				return response.OtherErrors(e, response.ErrorCSRFInvalid, "csrf token missing or invalid")
+			}
 		}
-		userId, err := accesstoken.Validate(t)
+		claims, err := accesstoken.Validate(t)
 		if err != nil {
 			if errors.Is(err, accesstoken.ErrorTokenExpired) {
 				return response.OtherErrors(e, response.ErrorAccessTokenExpired, "access token expired")
 			}
 			return response.UnauthorizedError(e)
 		}
-		e.Set("access-token-userid", userId)
+		if revoked != nil && revoked.IsRevoked(claims.SessionID) {
+			return response.OtherErrors(e, response.ErrorSessionRevoked, "session revoked")
+		}
+		if suspended != nil && suspended.IsSuspended(claims.UserID) {
+			return response.OtherErrors(e, response.ErrorUserSuspended, "user suspended")
+		}
+		if lastSeen != nil {
+			lastSeen.Touch(claims.SessionID)
+		}
+		e.Set("access-token-userid", claims.UserID)
+		e.Set("access-token-sessionid", claims.SessionID)
+		e.Set("access-token-role", claims.Role)
 		return next(e)
 	}
 }
 
+// PeekUserID - the user of the access token before any route checked it, for middlewares that run
+// before Auth, only the signature and expiry are checked, not revocation, suspension or csrf
+func PeekUserID(e echo.Context) (int64, bool) {
+	t := e.Request().Header.Get("Access-Token")
+	if t == "" {
+		t = cookieValue(e, AccessTokenCookie)
+	}
+	if t == "" {
+		return 0, false
+	}
+	claims, err := accesstoken.Validate(t)
+	if err != nil {
+		return 0, false
+	}
+	return claims.UserID, true
+}
+
+// GetUserID - for routes where auth is optional
+func GetUserID(e echo.Context) (int64, bool) {
+	userId, ok := e.Get("access-token-userid").(int64)
+	return userId, ok
+}
+
 func MustGetUserID(e echo.Context) int64 {
 	userId := e.Get("access-token-userid")
 	if userId != nil {
 		return userId.(int64)
 	}
 	panic("user id not found in context")
-	return 0
+}
+
+// GetRole - staff role from the access token, role.None for regular users
+func GetRole(e echo.Context) role.Role {
+	r, _ := e.Get("access-token-role").(role.Role)
+	return r
+}
+
+func MustGetSessionID(e echo.Context) int64 {
+	sessionId := e.Get("access-token-sessionid")
+	if sessionId != nil {
+		return sessionId.(int64)
+	}
+	panic("session id not found in context")
 }
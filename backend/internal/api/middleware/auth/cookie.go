@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"shogun/config"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	AccessTokenCookie  = "shogun_access"
+	RefreshTokenCookie = "shogun_refresh"
+	CSRFCookie         = "shogun_csrf"
+	CSRFHeader         = "X-CSRF-Token"
+	// SessionModeHeader - browsers send "cookie" to get the tokens as cookies, a custom header
+	// can't be sent cross-site without passing CORS so other sites can't log a browser in
+	SessionModeHeader = "X-Session-Mode"
+)
+
+// refreshCookiePath - the refresh token is only sent to the auth routes
+const refreshCookiePath = "/v1/auth"
+
+// WantsCookies - the client asked for a browser session and they are enabled
+func WantsCookies(e echo.Context) bool {
+	return config.Cfg.WebSessionsEnabled && e.Request().Header.Get(SessionModeHeader) == "cookie"
+}
+
+// SetSessionCookies - sets the HttpOnly token cookies and a new csrf cookie, the csrf token is
+// returned too so the client can keep it without reading cookies
+func SetSessionCookies(e echo.Context, accessToken string, accessExpires time.Duration, refreshToken string, refreshExpires time.Duration) string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	csrf := base64.RawURLEncoding.EncodeToString(b)
+	e.SetCookie(newCookie(AccessTokenCookie, accessToken, "/", accessExpires, true))
+	e.SetCookie(newCookie(RefreshTokenCookie, refreshToken, refreshCookiePath, refreshExpires, true))
+	//readable by the page, it has to copy it into the csrf header
+	e.SetCookie(newCookie(CSRFCookie, csrf, "/", refreshExpires, false))
+	return csrf
+}
+
+func ClearSessionCookies(e echo.Context) {
+	if !config.Cfg.WebSessionsEnabled {
+		return
+	}
+	e.SetCookie(newCookie(AccessTokenCookie, "", "/", -1, true))
+	e.SetCookie(newCookie(RefreshTokenCookie, "", refreshCookiePath, -1, true))
+	e.SetCookie(newCookie(CSRFCookie, "", "/", -1, false))
+}
+
+func newCookie(name, value, path string, maxAge time.Duration, httpOnly bool) *http.Cookie {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   config.Cfg.CookieDomain,
+		Secure:   config.Cfg.CookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite(),
+		MaxAge:   int(maxAge / time.Second),
+	}
+	if maxAge < 0 {
+		c.MaxAge = -1
+	}
+	return c
+}
+
+func sameSite() http.SameSite {
+	switch strings.ToLower(config.Cfg.CookieSameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// cookieValue - empty when web sessions are off or the cookie is missing
+func cookieValue(e echo.Context, name string) string {
+	if !config.Cfg.WebSessionsEnabled {
+		return ""
+	}
+	c, err := e.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// RefreshTokenFromCookie - the refresh token of a browser session
+func RefreshTokenFromCookie(e echo.Context) string {
+	return cookieValue(e, RefreshTokenCookie)
+}
+
+// ValidCSRF - double submit check, the header has to match the csrf cookie
+func ValidCSRF(e echo.Context) bool {
+	cookie := cookieValue(e, CSRFCookie)
+	header := e.Request().Header.Get(CSRFHeader)
+	if cookie == "" || header == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+// isSafeMethod - methods that never change state don't need the csrf header
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
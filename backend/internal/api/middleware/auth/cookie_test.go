@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"shogun/config"
+	"shogun/internal/api/response"
+	"shogun/internal/model/role"
+	"shogun/internal/security/accesstoken"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookieSession(t *testing.T) {
+	accesstoken.SetKeyring(accesstoken.NewEphemeralKeyring())
+	config.Cfg.WebSessionsEnabled = true
+	config.Cfg.AccessTokenMinutes = 15
+	defer func() {
+		config.Cfg.WebSessionsEnabled = false
+	}()
+	token, _ := accesstoken.GenerateTokenForUser(7, 9, role.None)
+
+	h := Auth(func(e echo.Context) error {
+		return response.JSON(e, MustGetUserID(e))
+	})
+	do := func(method string, cookies []*http.Cookie, csrf string) response.Status {
+		req := httptest.NewRequest(method, "/v1/user/update", nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		if csrf != "" {
+			req.Header.Set(CSRFHeader, csrf)
+		}
+		rec := httptest.NewRecorder()
+		assert.Nil(t, h(echo.New().NewContext(req, rec)))
+		res := &response.Response{}
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), res))
+		return res.Status
+	}
+
+	//login sets the cookies the browser sends back
+	rec := httptest.NewRecorder()
+	csrf := SetSessionCookies(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec), token, time.Minute, "refresh", time.Hour)
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 3)
+	for _, c := range cookies {
+		assert.Equal(t, c.Name != CSRFCookie, c.HttpOnly, c.Name)
+	}
+
+	assert.Equal(t, response.StatusOK, do(http.MethodGet, cookies, ""))
+	assert.Equal(t, response.ErrorCSRFInvalid, do(http.MethodPost, cookies, ""))
+	assert.Equal(t, response.ErrorCSRFInvalid, do(http.MethodPost, cookies, "wrong"))
+	assert.Equal(t, response.StatusOK, do(http.MethodPost, cookies, csrf))
+	assert.Equal(t, response.StatusUnauthorized, do(http.MethodGet, nil, ""))
+
+	config.Cfg.WebSessionsEnabled = false
+	assert.Equal(t, response.StatusUnauthorized, do(http.MethodGet, cookies, ""))
+}
@@ -0,0 +1,24 @@
+package ratelimiter
+
+import (
+	"time"
+)
+
+// Policy - how many requests one identity may make per window, the name keeps counters
+// of different policies apart so a route group never eats another group's budget
+type Policy struct {
+	Name   string
+	Limit  int64
+	Window time.Duration
+}
+
+var (
+	// Global - backstop per user, api key or IP for every route, high enough to never bother a real user
+	Global = Policy{Name: "global", Limit: 600, Window: time.Minute}
+	Login  = Policy{Name: "login", Limit: 20, Window: time.Minute}
+	Search = Policy{Name: "search", Limit: 120, Window: time.Minute}
+	Assets = Policy{Name: "assets", Limit: 60, Window: time.Minute}
+	Upload = Policy{Name: "upload", Limit: 20, Window: time.Hour}
+	// ContactRequest - sending contact requests, on top of the cap on pending ones
+	ContactRequest = Policy{Name: "contactrequest", Limit: 30, Window: time.Hour}
+)
@@ -1,28 +1,104 @@
 package ratelimiter
 
 import (
+	"fmt"
 	"net/http"
+	"shogun/internal/api/middleware/auth"
+	"shogun/internal/services/ratelimitstore"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog/log"
 )
 
-func InitEchoLimiter() echo.MiddlewareFunc {
-	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
-		Skipper: middleware.DefaultSkipper,
-		Store: middleware.NewRateLimiterMemoryStoreWithConfig(
-			middleware.RateLimiterMemoryStoreConfig{Rate: 15, Burst: 5, ExpiresIn: 5 * time.Minute},
-		),
-		IdentifierExtractor: func(ctx echo.Context) (string, error) {
-			id := ctx.RealIP()
-			return id, nil
-		},
-		ErrorHandler: func(context echo.Context, err error) error {
-			return context.JSON(http.StatusForbidden, nil)
-		},
-		DenyHandler: func(context echo.Context, identifier string, err error) error {
-			return context.JSON(http.StatusTooManyRequests, nil)
-		},
-	})
+// ipKeyReplacer - ipv6 colons are not allowed in nats kv keys
+var ipKeyReplacer = strings.NewReplacer(":", "_")
+
+type Limiter struct {
+	store ratelimitstore.Store
+}
+
+func New(store ratelimitstore.Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Global - the backstop for every route except /ping, used with echo's Use so it runs before
+// the routes authenticate, it reads the access token or api key itself to key on the user
+func (l *Limiter) Global() echo.MiddlewareFunc {
+	limit := l.middleware(Global, globalIdentity)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		limited := limit(next)
+		return func(e echo.Context) error {
+			if e.Path() == "/ping" {
+				return next(e)
+			}
+			return limited(e)
+		}
+	}
+}
+
+// Middleware - limits the route by the policy, keyed on the user when an earlier
+// middleware authenticated one and on the IP otherwise, so put it after auth.Auth
+func (l *Limiter) Middleware(p Policy) echo.MiddlewareFunc {
+	return l.middleware(p, identity)
+}
+
+func (l *Limiter) middleware(p Policy, identify func(echo.Context) string) echo.MiddlewareFunc {
+	window := int64(p.Window / time.Second)
+	policyHeader := fmt.Sprintf("%d;w=%d", p.Limit, window)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(e echo.Context) error {
+			now := time.Now().Unix()
+			windowStart := now - now%window
+			key := fmt.Sprintf("%s.%s.%d", p.Name, identify(e), windowStart)
+			count, err := l.store.Increment(key, p.Window)
+			if err != nil {
+				//a broken counter store shouldn't take the api down with it
+				log.Err(err).Str("policy", p.Name).Msg("rate limit check failed")
+				return next(e)
+			}
+
+			reset := windowStart + window - now
+			h := e.Response().Header()
+			h.Set("RateLimit-Policy", policyHeader)
+			h.Set("RateLimit-Limit", strconv.FormatInt(p.Limit, 10))
+			h.Set("RateLimit-Remaining", strconv.FormatInt(max(p.Limit-count, 0), 10))
+			h.Set("RateLimit-Reset", strconv.FormatInt(reset, 10))
+			if count > p.Limit {
+				h.Set("Retry-After", strconv.FormatInt(reset, 10))
+				return e.JSON(http.StatusTooManyRequests, nil)
+			}
+			return next(e)
+		}
+	}
+}
+
+// identity - anonymous callers are keyed on RealIP, which only trusts X-Forwarded-For from
+// the proxies api.Init configures on echo's IPExtractor
+func identity(e echo.Context) string {
+	if keyID, ok := auth.GetApiKeyID(e); ok {
+		return fmt.Sprintf("k%d", keyID)
+	}
+	if userID, ok := auth.GetUserID(e); ok {
+		return fmt.Sprintf("u%d", userID)
+	}
+	return ipIdentity(e)
+}
+
+// globalIdentity - like identity, for requests no auth middleware has seen yet, made up
+// tokens and keys don't pass the checks and fall back to the IP
+func globalIdentity(e echo.Context) string {
+	if keyID, ok := auth.PeekApiKeyID(e); ok {
+		return fmt.Sprintf("k%d", keyID)
+	}
+	if userID, ok := auth.PeekUserID(e); ok {
+		return fmt.Sprintf("u%d", userID)
+	}
+	return ipIdentity(e)
+}
+
+func ipIdentity(e echo.Context) string {
+	return "ip" + ipKeyReplacer.Replace(e.RealIP())
 }
@@ -0,0 +1,83 @@
+package ratelimiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"shogun/config"
+	"shogun/internal/model/role"
+	"shogun/internal/security/accesstoken"
+	"shogun/internal/services/ratelimitstore"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	p := Policy{Name: "test", Limit: 2, Window: time.Hour}
+	limit := New(ratelimitstore.NewMemory()).Middleware(p)
+	h := limit(func(e echo.Context) error {
+		return e.NoContent(http.StatusOK)
+	})
+
+	do := func(ip string, userID int64) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/user/profile", nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		e := echo.New().NewContext(req, rec)
+		if userID != 0 {
+			e.Set("access-token-userid", userID)
+		}
+		assert.Nil(t, h(e))
+		return rec
+	}
+
+	rec := do("10.0.0.1", 0)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=3600", rec.Header().Get("RateLimit-Policy"))
+
+	assert.Equal(t, http.StatusOK, do("10.0.0.1", 0).Code)
+	rec = do("10.0.0.1", 0)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	//logged-in users behind the same IP have their own budget
+	assert.Equal(t, http.StatusOK, do("10.0.0.1", 7).Code)
+	assert.Equal(t, http.StatusOK, do("10.0.0.1", 8).Code)
+	assert.Equal(t, http.StatusOK, do("10.0.0.1", 7).Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("10.0.0.2", 7).Code)
+}
+
+func TestLimiter_Global(t *testing.T) {
+	accesstoken.SetKeyring(accesstoken.NewEphemeralKeyring())
+	config.Cfg.AccessTokenMinutes = 15
+	token, _ := accesstoken.GenerateTokenForUser(7, 9, role.None)
+	other, _ := accesstoken.GenerateTokenForUser(8, 10, role.None)
+
+	l := New(ratelimitstore.NewMemory())
+	h := l.Global()(func(e echo.Context) error {
+		return e.NoContent(http.StatusOK)
+	})
+	do := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/user/me", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if token != "" {
+			req.Header.Set("Access-Token", token)
+		}
+		rec := httptest.NewRecorder()
+		assert.Nil(t, h(echo.New().NewContext(req, rec)))
+		return rec.Code
+	}
+	for range Global.Limit {
+		assert.Equal(t, http.StatusOK, do(""))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, do(""))
+	assert.Equal(t, http.StatusTooManyRequests, do("made-up"))
+	//users behind the same carrier NAT don't share the IP's budget
+	assert.Equal(t, http.StatusOK, do(token))
+	assert.Equal(t, http.StatusOK, do(other))
+}
@@ -0,0 +1,48 @@
+package realip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Extractor - how echo's RealIP finds the client. X-Forwarded-For is written by whoever sends the
+// request, so without trusted proxies only the address of the connection counts. With them the
+// right-most X-Forwarded-For entry that isn't one of the proxies is the client, anything the
+// client put further left is ignored
+func Extractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	//only the configured ranges, a client on the same private network is still a client
+	opts := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		ipRange, err := parseRange(strings.TrimSpace(proxy))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(opts...), nil
+}
+
+// parseRange - a cidr, or a single ip
+func parseRange(proxy string) (*net.IPNet, error) {
+	if strings.Contains(proxy, "/") {
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		return ipRange, nil
+	}
+	ip := net.ParseIP(proxy)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
@@ -0,0 +1,70 @@
+package realip
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"shogun/internal/services/ratelimitstore"
+	"shogun/internal/services/signupguard"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func realIP(t *testing.T, trusted []string, remote string, xff ...string) string {
+	extractor, err := Extractor(trusted)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remote + ":1234"
+	for _, v := range xff {
+		req.Header.Add(echo.HeaderXForwardedFor, v)
+	}
+	return extractor(req)
+}
+
+func TestExtractor(t *testing.T) {
+	//nothing trusted, the header is ignored
+	assert.Equal(t, "203.0.113.7", realIP(t, nil, "203.0.113.7", "1.2.3.4"))
+
+	//behind the proxy the entry it added is the client, the ones the client sent aren't
+	proxies := []string{"10.0.0.0/8", "192.0.2.1"}
+	assert.Equal(t, "203.0.113.7", realIP(t, proxies, "10.1.1.1", "203.0.113.7"))
+	assert.Equal(t, "203.0.113.7", realIP(t, proxies, "10.1.1.1", "1.2.3.4, 203.0.113.7"))
+	assert.Equal(t, "203.0.113.7", realIP(t, proxies, "192.0.2.1", "1.2.3.4", "203.0.113.7, 10.2.2.2"))
+
+	//a client reaching the api directly can't pick its ip
+	assert.Equal(t, "203.0.113.7", realIP(t, proxies, "203.0.113.7", "1.2.3.4"))
+	//private ranges aren't trusted unless configured
+	assert.Equal(t, "172.16.0.9", realIP(t, proxies, "172.16.0.9", "1.2.3.4"))
+
+	_, err := Extractor([]string{"10.0.0.0/99"})
+	assert.Error(t, err)
+	_, err = Extractor([]string{"proxy"})
+	assert.Error(t, err)
+}
+
+func TestExtractor_SpoofedHeaderKeepsSignupBudget(t *testing.T) {
+	for _, trusted := range [][]string{nil, {"10.0.0.0/8"}} {
+		extractor, err := Extractor(trusted)
+		assert.NoError(t, err)
+		e := echo.New()
+		e.IPExtractor = extractor
+		guard := signupguard.New(ratelimitstore.NewMemory(), 2, 0, 0)
+
+		check := func(i int) error {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "203.0.113.7:1234"
+			if trusted != nil {
+				req.RemoteAddr = "10.0.0.1:1234"
+				req.Header.Set(echo.HeaderXForwardedFor, fmt.Sprintf("198.51.100.%d, 203.0.113.7", i))
+			} else {
+				req.Header.Set(echo.HeaderXForwardedFor, fmt.Sprintf("198.51.100.%d", i))
+			}
+			return guard.Check(signupguard.Attempt{IP: e.NewContext(req, httptest.NewRecorder()).RealIP()})
+		}
+		assert.NoError(t, check(1))
+		assert.NoError(t, check(2))
+		assert.ErrorIs(t, check(3), signupguard.ErrorThrottled, "trusted %v", trusted)
+	}
+}
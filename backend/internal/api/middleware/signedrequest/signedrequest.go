@@ -0,0 +1,182 @@
+package signedrequest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"shogun/internal/api/middleware/auth"
+	"shogun/internal/api/response"
+	"shogun/internal/model/chain"
+	"shogun/internal/model/challenge"
+	"shogun/internal/services/accountstore"
+	"shogun/internal/services/challengestore"
+	"shogun/internal/services/siglocker"
+	"shogun/internal/services/signverifier"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderSignature = "X-Signature"
+	HeaderAddress   = "X-Signature-Address"
+	HeaderChain     = "X-Signature-Chain"
+	HeaderTimestamp = "X-Signature-Timestamp"
+	HeaderNonce     = "X-Signature-Nonce"
+
+	canonicalVersion = "shogun-signed-request-v1"
+)
+
+var (
+	errorMissingHeaders = errors.New("signature headers missing")
+	errorNotLinked      = errors.New("address is not linked to the user")
+	errorStale          = errors.New("request timestamp outside the allowed window")
+	errorBodyTooLarge   = errors.New("body too large to sign")
+	errorBadSignature   = errors.New("invalid signature")
+)
+
+type Config struct {
+	Accounts   accountstore.Store
+	Challenges challengestore.Store
+	Replay     siglocker.UseChecker
+	// MaxAge - how far the timestamp may be from the server clock, either way
+	MaxAge time.Duration
+	// MaxBodyBytes - bodies above this can't be hashed and are rejected
+	MaxBodyBytes int64
+	// PrimaryOnly - only the login key may sign, otherwise any linked account
+	PrimaryOnly bool
+	// Optional - unsigned requests pass, signed ones are still verified,
+	// lets clients move over before signing is enforced
+	Optional bool
+}
+
+// Canonical - the exact text a client signs, one field per line:
+// version, method, path, query sorted by key, hex sha256 of the body and
+// either "timestamp:<unix ms>" or "nonce:<challenge nonce>"
+func Canonical(method, path, query string, body []byte, freshness string) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		canonicalVersion,
+		strings.ToUpper(method),
+		path,
+		query,
+		hex.EncodeToString(bodyHash[:]),
+		freshness,
+	}, "\n")
+}
+
+// New - verifies the request was signed by a key linked to the logged-in user,
+// it has to run after auth.Auth
+func New(conf Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(e echo.Context) error {
+			err := conf.verify(e)
+			if errors.Is(err, errorMissingHeaders) && conf.Optional {
+				return next(e)
+			}
+			if err != nil {
+				return signatureError(e, err)
+			}
+			return next(e)
+		}
+	}
+}
+
+func (conf Config) verify(e echo.Context) error {
+	req := e.Request()
+	signature := req.Header.Get(HeaderSignature)
+	address := req.Header.Get(HeaderAddress)
+	c := chain.Chain(req.Header.Get(HeaderChain))
+	timestamp := req.Header.Get(HeaderTimestamp)
+	nonce := req.Header.Get(HeaderNonce)
+	if signature == "" || address == "" || c == "" || (timestamp == "" && nonce == "") {
+		return errorMissingHeaders
+	}
+
+	userID := auth.MustGetUserID(e)
+	acc, err := conf.Accounts.GetAccount(address, c)
+	if err != nil {
+		if errors.Is(err, accountstore.ErrorAccountNotFound) {
+			return errorNotLinked
+		}
+		return err
+	}
+	if acc.UserID != userID || (conf.PrimaryOnly && !acc.IsPrimary) {
+		return errorNotLinked
+	}
+
+	body, err := conf.readBody(req.Body)
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	var freshness string
+	if nonce != "" {
+		ch, err := conf.Challenges.Consume(nonce)
+		if err != nil {
+			if errors.Is(err, challengestore.ErrorChallengeNotFound) {
+				return errorStale
+			}
+			return err
+		}
+		if ch.Action != challenge.ActionRequest || ch.Address != address || ch.Chain != c {
+			return errorStale
+		}
+		freshness = "nonce:" + nonce
+	} else {
+		ms, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return errorStale
+		}
+		age := time.Since(time.UnixMilli(ms))
+		if age > conf.MaxAge || age < -conf.MaxAge {
+			return errorStale
+		}
+		freshness = "timestamp:" + timestamp
+	}
+
+	message := Canonical(req.Method, req.URL.Path, req.URL.Query().Encode(), body, freshness)
+	if !signverifier.Verify(c, message, address, signature) {
+		return errorBadSignature
+	}
+	//only marked once it's known to be valid, garbage signatures don't fill the store
+	return conf.Replay.Use(signature)
+}
+
+func (conf Config) readBody(body io.Reader) ([]byte, error) {
+	if body == nil {
+		return []byte{}, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(body, conf.MaxBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > conf.MaxBodyBytes {
+		return nil, errorBodyTooLarge
+	}
+	return data, nil
+}
+
+func signatureError(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, errorMissingHeaders):
+		return response.OtherErrors(e, response.ErrorSignatureRequired, "request must be signed")
+	case errors.Is(err, errorNotLinked):
+		return response.OtherErrors(e, response.ErrorSignatureInvalid, "address is not linked to this account")
+	case errors.Is(err, errorStale):
+		return response.OtherErrors(e, response.ErrorSignatureInvalid, "signature expired")
+	case errors.Is(err, siglocker.ErrorSignatureUsed):
+		return response.OtherErrors(e, response.ErrorSignatureInvalid, "signature already used")
+	case errors.Is(err, errorBadSignature):
+		return response.OtherErrors(e, response.ErrorSignatureInvalid, "invalid signature")
+	case errors.Is(err, errorBodyTooLarge):
+		return response.BadRequestError(e, "body too large")
+	default:
+		return response.ServerError(e, err, "")
+	}
+}
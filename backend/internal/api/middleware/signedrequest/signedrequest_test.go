@@ -0,0 +1,117 @@
+package signedrequest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"shogun/internal/api/response"
+	"shogun/internal/model/account"
+	"shogun/internal/model/chain"
+	"shogun/internal/services/accountstore"
+	"shogun/internal/services/siglocker"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAccounts struct {
+	accountstore.Store
+	accounts map[string]*account.Account
+}
+
+func (f *fakeAccounts) GetAccount(address string, _ chain.Chain) (*account.Account, error) {
+	a, ok := f.accounts[address]
+	if !ok {
+		return nil, accountstore.ErrorAccountNotFound
+	}
+	return a, nil
+}
+
+type signer struct {
+	address string
+	private ed25519.PrivateKey
+}
+
+func newSigner(t *testing.T) *signer {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	return &signer{address: solana.PublicKeyFromBytes(public).String(), private: private}
+}
+
+func (s *signer) request(method, target, body string, ts time.Time) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	timestamp := strconv.FormatInt(ts.UnixMilli(), 10)
+	message := Canonical(method, req.URL.Path, req.URL.Query().Encode(), []byte(body), "timestamp:"+timestamp)
+	sig := ed25519.Sign(s.private, []byte(message))
+	req.Header.Set(HeaderSignature, solana.SignatureFromBytes(sig).String())
+	req.Header.Set(HeaderAddress, s.address)
+	req.Header.Set(HeaderChain, string(chain.Solana))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	return req
+}
+
+func serve(conf Config, req *http.Request, userID int64) response.Status {
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("access-token-userid", userID)
+	h := New(conf)(func(c echo.Context) error {
+		return response.Success(c)
+	})
+	_ = h(c)
+	res := &response.Response{}
+	_ = json.Unmarshal(rec.Body.Bytes(), res)
+	return res.Status
+}
+
+func TestSignedRequest(t *testing.T) {
+	owner := newSigner(t)
+	other := newSigner(t)
+	conf := Config{
+		Accounts: &fakeAccounts{accounts: map[string]*account.Account{
+			owner.address: {UserID: 1, Address: owner.address, Chain: chain.Solana, IsPrimary: true},
+			other.address: {UserID: 2, Address: other.address, Chain: chain.Solana, IsPrimary: true},
+		}},
+		Replay:       siglocker.NewMemory(time.Minute),
+		MaxAge:       time.Minute,
+		MaxBodyBytes: 1024,
+	}
+
+	body := `{"bio":"hi"}`
+	req := owner.request(http.MethodPost, "/v1/user/update?b=2&a=1", body, time.Now())
+	headers := req.Header.Clone()
+	assert.Equal(t, response.StatusOK, serve(conf, req, 1))
+
+	//same signature again
+	replay := httptest.NewRequest(http.MethodPost, "/v1/user/update?b=2&a=1", strings.NewReader(body))
+	replay.Header = headers
+	assert.Equal(t, response.ErrorSignatureInvalid, serve(conf, replay, 1))
+
+	//body changed after signing
+	tampered := owner.request(http.MethodPost, "/v1/user/update", body, time.Now())
+	tampered.Body = io.NopCloser(strings.NewReader(`{"bio":"bye"}`))
+	assert.Equal(t, response.ErrorSignatureInvalid, serve(conf, tampered, 1))
+
+	//too old
+	stale := owner.request(http.MethodPost, "/v1/user/update", `{}`, time.Now().Add(-2*time.Minute))
+	assert.Equal(t, response.ErrorSignatureInvalid, serve(conf, stale, 1))
+
+	//key linked to someone else
+	foreign := other.request(http.MethodPost, "/v1/user/update", `{}`, time.Now())
+	assert.Equal(t, response.ErrorSignatureInvalid, serve(conf, foreign, 1))
+
+	//unsigned
+	unsigned := httptest.NewRequest(http.MethodPost, "/v1/user/update", strings.NewReader(`{}`))
+	assert.Equal(t, response.ErrorSignatureRequired, serve(conf, unsigned, 1))
+	conf.Optional = true
+	unsigned = httptest.NewRequest(http.MethodPost, "/v1/user/update", strings.NewReader(`{}`))
+	assert.Equal(t, response.StatusOK, serve(conf, unsigned, 1))
+}
@@ -13,6 +13,7 @@ const (
 	StatusOK           Status = 200
 	StatusBadRequest   Status = 400
 	StatusUnauthorized Status = 401
+	StatusForbidden    Status = 403
 
 	StatusServerError Status = 500
 
@@ -21,6 +22,29 @@ const (
 	ErrorUpdateUsernameBlocked      Status = 4002
 	ErrorUpdateNameBlocked          Status = 4003
 	ErrorChainNotSupportedForAction Status = 4004
+	ErrorSessionRevoked             Status = 4005
+	ErrorRefreshTokenInvalid        Status = 4006
+	ErrorNotPrimaryAccount          Status = 4007
+	ErrorAccountAlreadyLinked       Status = 4008
+	ErrorCannotUnlinkPrimary        Status = 4009
+	ErrorLastAccount                Status = 4010
+	ErrorSessionNotFound            Status = 4011
+	ErrorSignatureRequired          Status = 4012
+	ErrorSignatureInvalid           Status = 4013
+	ErrorUserSuspended              Status = 4014
+	ErrorUsernameTaken              Status = 4015
+	ErrorApiKeyInvalid              Status = 4016
+	ErrorApiKeyLimit                Status = 4017
+	ErrorPairingNotFound            Status = 4018
+	ErrorPairingResolved            Status = 4019
+	ErrorCSRFInvalid                Status = 4020
+	ErrorSignupThrottled            Status = 4021
+	ErrorProofOfWorkRequired        Status = 4022
+	ErrorUsernameReserved           Status = 4023
+	ErrorUsernameQuarantined        Status = 4024
+	ErrorContactRequestNotFound     Status = 4025
+	ErrorNotContacts                Status = 4026
+	ErrorTooManyContactRequests     Status = 4027
 )
 
 type Response struct {
@@ -44,6 +68,10 @@ func UnauthorizedError(e echo.Context) error {
 	return OtherErrors(e, StatusUnauthorized, "")
 }
 
+func ForbiddenError(e echo.Context) error {
+	return OtherErrors(e, StatusForbidden, "")
+}
+
 func BadRequestError(e echo.Context, msg string) error {
 	r := &Response{Status: StatusBadRequest, Error: msg}
 	log.Warn().Str("api", e.Request().RequestURI).Int("code", int(StatusBadRequest)).Msg(msg)
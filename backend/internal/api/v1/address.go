@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"shogun/internal/api/response"
+	"shogun/internal/model/chain"
+	"shogun/internal/model/risk"
+	"shogun/internal/services/screening"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const screenMaxAddresses = 50
+
+type AddressController struct {
+	screener screening.Screener
+}
+
+func NewAddressController(screener screening.Screener) *AddressController {
+	return &AddressController{
+		screener: screener,
+	}
+}
+
+type screenQuery struct {
+	Address string      `query:"address"`
+	Chain   chain.Chain `query:"chain"`
+}
+
+// @Title Screen addresses
+// @Description Checks addresses against the sanctions and scam lists before sending to them.
+// @Description level is block for sanctioned addresses, warn for anything else on a list and none for clean ones.
+// @Param address query string true "Address, or up to 50 comma separated"
+// @Param chain query string true "Chain of the addresses"
+// @Success 200 {array} risk.Result
+// @Route /address/screen [get]
+func (ac *AddressController) Screen(e echo.Context) error {
+	query := &screenQuery{}
+	if err := e.Bind(query); err != nil || query.Address == "" {
+		return response.BadRequestError(e, "address is required")
+	}
+	if !query.Chain.IsSupported() {
+		return response.BadRequestError(e, "unsupported chain")
+	}
+	addresses := strings.Split(query.Address, ",")
+	if len(addresses) > screenMaxAddresses {
+		return response.BadRequestError(e, "too many addresses")
+	}
+	results := make([]risk.Result, 0, len(addresses))
+	for _, address := range addresses {
+		address = strings.TrimSpace(address)
+		results = append(results, risk.NewResult(address, query.Chain, ac.screener.Screen(address, query.Chain)))
+	}
+	return response.JSON(e, results)
+}
@@ -0,0 +1,311 @@
+package v1
+
+import (
+	"errors"
+	"shogun/internal/api/middleware/auth"
+	"shogun/internal/api/response"
+	"shogun/internal/model/account"
+	"shogun/internal/model/audit"
+	"shogun/internal/model/chain"
+	"shogun/internal/model/role"
+	"shogun/internal/model/token"
+	"shogun/internal/model/user"
+	"shogun/internal/services/accountstore"
+	"shogun/internal/services/auditstore"
+	"shogun/internal/services/rolestore"
+	"shogun/internal/services/signupguard"
+	"shogun/internal/services/suspension"
+	"shogun/internal/services/tokenstore"
+	"shogun/internal/services/userinfosync"
+	"shogun/internal/services/userstore"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AdminController struct {
+	userService    userstore.Store
+	accountService accountstore.Store
+	roleService    rolestore.Store
+	tokenService   tokenstore.Store
+	suspension     suspension.Checker
+	userSync       userinfosync.Service
+	auditService   auditstore.Store
+	signupGuard    *signupguard.Guard
+}
+
+func NewAdminController(
+	us userstore.Store,
+	as accountstore.Store,
+	rs rolestore.Store,
+	ts tokenstore.Store,
+	sc suspension.Checker,
+	usync userinfosync.Service,
+	aus auditstore.Store,
+	sg *signupguard.Guard,
+) *AdminController {
+	return &AdminController{
+		userService:    us,
+		accountService: as,
+		roleService:    rs,
+		tokenService:   ts,
+		suspension:     sc,
+		userSync:       usync,
+		auditService:   aus,
+		signupGuard:    sg,
+	}
+}
+
+// @Enum adminUserResponse
+type adminUserResponse struct {
+	user.User
+	Accounts []account.Simple `json:"accounts"`
+	Role     role.Role        `json:"role"`
+}
+
+type suspendParams struct {
+	Reason string `json:"reason"`
+}
+
+type renameParams struct {
+	Username string `json:"username"`
+}
+
+type roleParams struct {
+	Role role.Role `json:"role"`
+}
+
+// targetUserID - the :id path param, the user an admin action is about
+func targetUserID(e echo.Context) (int64, error) {
+	return strconv.ParseInt(e.Param("id"), 10, 64)
+}
+
+// adminError - maps store errors of admin actions to api responses
+func adminError(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, userstore.ErrorUserNotFound), errors.Is(err, accountstore.ErrorAccountNotFound):
+		return response.OtherErrors(e, response.ErrorUserNotFound, "user not found")
+	case errors.Is(err, userstore.ErrorDuplicateUsername),
+		errors.Is(err, userstore.ErrorUsernameReserved),
+		errors.Is(err, userstore.ErrorUsernameQuarantined):
+		return usernameError(e, err)
+	case errors.Is(err, userstore.ErrorReservedWordNotFound):
+		return response.BadRequestError(e, "reserved word not found")
+	case errors.Is(err, tokenstore.ErrorTokenNotFound):
+		return response.BadRequestError(e, "token not found")
+	default:
+		return response.ServerError(e, err, "")
+	}
+}
+
+// @Title Find user
+// @Description Finds a user by exactly one of id, username or address, with their accounts, role and suspension.
+// @Param id query string false "User id"
+// @Param username query string false "Username"
+// @Param address query string false "Any linked address"
+// @Param chain query string false "Chain of the address, defaults to solana"
+// @Success 200 {object} adminUserResponse
+// @Route /admin/users [get]
+func (ac *AdminController) FindUser(e echo.Context) error {
+	var userID int64
+	var err error
+	switch {
+	case e.QueryParam("id") != "":
+		userID, err = strconv.ParseInt(e.QueryParam("id"), 10, 64)
+		if err != nil {
+			return response.BadRequestError(e, "invalid id")
+		}
+	case e.QueryParam("username") != "":
+		u, err := ac.userService.GetSimpleByUsername(e.QueryParam("username"))
+		if err != nil {
+			return adminError(e, err)
+		}
+		userID = u.ID
+	case e.QueryParam("address") != "":
+		c := chain.Chain(e.QueryParam("chain"))
+		if c == "" {
+			c = chain.Solana
+		}
+		acc, err := ac.accountService.GetAccount(e.QueryParam("address"), c)
+		if err != nil {
+			return adminError(e, err)
+		}
+		userID = acc.UserID
+	default:
+		return response.BadRequestError(e, "id, username or address is required")
+	}
+
+	u, err := ac.userService.GetOne(userID)
+	if err != nil {
+		return adminError(e, err)
+	}
+	accounts, err := ac.accountService.GetSimpleByUserID(userID)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	r, err := ac.roleService.Get(userID)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, adminUserResponse{
+		User:     *u,
+		Accounts: accounts,
+		Role:     r,
+	})
+}
+
+// @Title Suspend user
+// @Description Suspended users can't log in or refresh and their access tokens are rejected right away.
+// @Param id path string true "User id"
+// @Param body body suspendParams true "reason shown to other staff"
+// @Success 200 success
+// @Route /admin/users/{id}/suspend [post]
+func (ac *AdminController) Suspend(e echo.Context) error {
+	userID, err := targetUserID(e)
+	if err != nil {
+		return response.BadRequestError(e, "invalid id")
+	}
+	if userID == auth.MustGetUserID(e) {
+		return response.BadRequestError(e, "can't suspend yourself")
+	}
+	params := &suspendParams{}
+	if err = e.Bind(params); err != nil || strings.TrimSpace(params.Reason) == "" {
+		return response.BadRequestError(e, "reason is required")
+	}
+	if err = ac.userService.Suspend(userID, params.Reason); err != nil {
+		return adminError(e, err)
+	}
+	if err = ac.suspension.Suspended(userID, true); err != nil {
+		return response.ServerError(e, err, "")
+	}
+	recordEvent(ac.auditService, e, userID, audit.ActionUserSuspend, "", audit.Payload{"reason": params.Reason})
+	return response.Success(e)
+}
+
+// @Title Unsuspend user
+// @Param id path string true "User id"
+// @Success 200 success
+// @Route /admin/users/{id}/unsuspend [post]
+func (ac *AdminController) Unsuspend(e echo.Context) error {
+	userID, err := targetUserID(e)
+	if err != nil {
+		return response.BadRequestError(e, "invalid id")
+	}
+	if err = ac.userService.Unsuspend(userID); err != nil {
+		return adminError(e, err)
+	}
+	if err = ac.suspension.Suspended(userID, false); err != nil {
+		return response.ServerError(e, err, "")
+	}
+	recordEvent(ac.auditService, e, userID, audit.ActionUserUnsuspend, "", nil)
+	return response.Success(e)
+}
+
+// @Title Force username change
+// @Description Changes the username regardless of the update lock, the lock starts again after it.
+// @Param id path string true "User id"
+// @Param body body renameParams true "new username"
+// @Success 200 success
+// @Route /admin/users/{id}/username [post]
+func (ac *AdminController) Rename(e echo.Context) error {
+	userID, err := targetUserID(e)
+	if err != nil {
+		return response.BadRequestError(e, "invalid id")
+	}
+	params := &renameParams{}
+	if err = e.Bind(params); err != nil || !user.IsUsernameValid(params.Username) {
+		return response.BadRequestError(e, "username is invalid")
+	}
+	updatable := user.Updatable{Username: &params.Username}
+	if err = ac.userService.Update(userID, &updatable); err != nil {
+		return adminError(e, err)
+	}
+	if err = ac.userSync.Update(userID, updatable); err != nil {
+		return response.ServerError(e, err, "")
+	}
+	recordEvent(ac.auditService, e, userID, audit.ActionUsernameForced, params.Username, nil)
+	return response.Success(e)
+}
+
+// @Title Reset username lock
+// @Description Lets the user change their username again without waiting for the lock.
+// @Param id path string true "User id"
+// @Success 200 success
+// @Route /admin/users/{id}/username/unlock [post]
+func (ac *AdminController) ResetUsernameLock(e echo.Context) error {
+	userID, err := targetUserID(e)
+	if err != nil {
+		return response.BadRequestError(e, "invalid id")
+	}
+	if err = ac.userService.ResetUsernameLock(userID); err != nil {
+		return adminError(e, err)
+	}
+	recordEvent(ac.auditService, e, userID, audit.ActionUsernameUnlock, "", nil)
+	return response.Success(e)
+}
+
+// @Title Set role
+// @Description Grants a staff role, an empty role removes it. Every change is logged with who made it.
+// @Param id path string true "User id"
+// @Param body body roleParams true "admin, support or empty"
+// @Success 200 success
+// @Route /admin/users/{id}/role [post]
+func (ac *AdminController) SetRole(e echo.Context) error {
+	userID, err := targetUserID(e)
+	if err != nil {
+		return response.BadRequestError(e, "invalid id")
+	}
+	actorID := auth.MustGetUserID(e)
+	if userID == actorID {
+		return response.BadRequestError(e, "can't change your own role")
+	}
+	params := &roleParams{}
+	if err = e.Bind(params); err != nil || !params.Role.IsValid() {
+		return response.BadRequestError(e, "invalid role")
+	}
+	if _, err = ac.userService.GetSimpleByID(userID); err != nil {
+		return adminError(e, err)
+	}
+	if err = ac.roleService.Set(userID, params.Role, actorID); err != nil {
+		return response.ServerError(e, err, "")
+	}
+	recordEvent(ac.auditService, e, userID, audit.ActionRoleChange, string(params.Role), nil)
+	return response.Success(e)
+}
+
+// @Title Override token
+// @Description Corrects the name, symbol, decimals or logo of a stored token, omitted fields are kept.
+// @Param address path string true "Token address"
+// @Param chain query string true "Token chain"
+// @Param body body token.Override true "fields to override"
+// @Success 200 {object} token.Token
+// @Route /admin/tokens/{address} [post]
+func (ac *AdminController) OverrideToken(e echo.Context) error {
+	c := chain.Chain(e.QueryParam("chain"))
+	if !c.IsSupported() {
+		return response.BadRequestError(e, "unsupported chain")
+	}
+	o := &token.Override{}
+	if err := e.Bind(o); err != nil {
+		return response.BadRequestError(e, "")
+	}
+	if o.Name != nil && (*o.Name == "" || len(*o.Name) > 50) {
+		return response.BadRequestError(e, "invalid name")
+	}
+	if o.Symbol != nil && (*o.Symbol == "" || len(*o.Symbol) > 15) {
+		return response.BadRequestError(e, "invalid symbol")
+	}
+	if o.Decimals != nil && (*o.Decimals < 0 || *o.Decimals > 36) {
+		return response.BadRequestError(e, "invalid decimals")
+	}
+	if o.Logo != nil && !strings.HasPrefix(*o.Logo, "https://") && !strings.HasPrefix(*o.Logo, "data:image/") {
+		return response.BadRequestError(e, "logo must be an https url or a data:image uri")
+	}
+	t, err := ac.tokenService.Override(e.Param("address"), c, o)
+	if err != nil {
+		return adminError(e, err)
+	}
+	recordEvent(ac.auditService, e, auth.MustGetUserID(e), audit.ActionTokenOverride, t.Address, audit.Payload{"chain": c})
+	return response.JSON(e, t)
+}
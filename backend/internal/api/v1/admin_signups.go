@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"shogun/internal/api/response"
+	"shogun/internal/model/audit"
+	"shogun/internal/services/signupguard"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	signupMetricsMaxHours = 24 * 7
+	signupTopSources      = 20
+	//enough ips to add up the busiest subnets
+	signupSourcesScanned = 1000
+)
+
+// @Enum signupMetricsResponse
+type signupMetricsResponse struct {
+	Hours           []audit.HourCount   `json:"hours"`
+	TopIPs          []audit.SourceCount `json:"top_ips"`
+	TopSubnets      []audit.SourceCount `json:"top_subnets"`
+	PerIPHourly     int64               `json:"per_ip_hourly"`
+	PerSubnetHourly int64               `json:"per_subnet_hourly"`
+	PowDifficulty   int                 `json:"pow_difficulty"`
+}
+
+// @Title Sign up metrics
+// @Description Sign ups and blocked sign ups per hour, the busiest ips and subnets, and the limits in force.
+// @Param hours query int false "How far back, default 24, max 168"
+// @Success 200 {object} signupMetricsResponse
+// @Route /admin/signups [get]
+func (ac *AdminController) SignupMetrics(e echo.Context) error {
+	hours, _ := strconv.Atoi(e.QueryParam("hours"))
+	if hours <= 0 || hours > signupMetricsMaxHours {
+		hours = 24
+	}
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	velocity, err := ac.auditService.SignupVelocity(since)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	ips, err := ac.auditService.SignupSources(since, signupSourcesScanned)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	perIP, perSubnet := ac.signupGuard.Limits()
+	return response.JSON(e, signupMetricsResponse{
+		Hours:           velocity,
+		TopIPs:          ips[:min(len(ips), signupTopSources)],
+		TopSubnets:      bySubnet(ips),
+		PerIPHourly:     perIP,
+		PerSubnetHourly: perSubnet,
+		PowDifficulty:   ac.signupGuard.Difficulty(),
+	})
+}
+
+// bySubnet - adds up the ip counts per subnet, busiest first
+func bySubnet(ips []audit.SourceCount) []audit.SourceCount {
+	totals := make(map[string]*audit.SourceCount)
+	for _, ip := range ips {
+		subnet := signupguard.Subnet(ip.Source)
+		if subnet == "" {
+			continue
+		}
+		t, ok := totals[subnet]
+		if !ok {
+			t = &audit.SourceCount{Source: subnet}
+			totals[subnet] = t
+		}
+		t.SignUps += ip.SignUps
+		t.Blocked += ip.Blocked
+	}
+	subnets := make([]audit.SourceCount, 0, len(totals))
+	for _, t := range totals {
+		subnets = append(subnets, *t)
+	}
+	slices.SortFunc(subnets, func(a, b audit.SourceCount) int {
+		return int((b.SignUps + b.Blocked) - (a.SignUps + a.Blocked))
+	})
+	return subnets[:min(len(subnets), signupTopSources)]
+}
@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"shogun/config"
+	"shogun/internal/api/middleware/auth"
+	"shogun/internal/api/response"
+	"shogun/internal/model/audit"
+	"shogun/internal/model/user"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// @Enum reservedWordParams
+type reservedWordParams struct {
+	Word string `json:"word"`
+	//Match is exact or contains, exact by default
+	Match user.ReservedMatch `json:"match"`
+}
+
+// @Title Reserved usernames
+// @Description Words nobody can take as a username.
+// @Success 200 {array} user.ReservedWord
+// @Route /admin/reserved-usernames [get]
+func (ac *AdminController) ReservedWords(e echo.Context) error {
+	words, err := ac.userService.GetReservedWords()
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, words)
+}
+
+// @Title Reserve username
+// @Description Blocks usernames equal to the word, or containing it with match contains. Users who already have one keep it.
+// @Param body body reservedWordParams true "word and how it matches"
+// @Success 200 success
+// @Route /admin/reserved-usernames [post]
+func (ac *AdminController) AddReservedWord(e echo.Context) error {
+	params := &reservedWordParams{}
+	if err := e.Bind(params); err != nil {
+		return response.BadRequestError(e, "invalid request body")
+	}
+	params.Word = strings.TrimPrefix(strings.TrimSpace(params.Word), "@")
+	if params.Word == "" || len(params.Word) > config.Cfg.UsernameMaxLength {
+		return response.BadRequestError(e, "word is invalid")
+	}
+	if params.Match == "" {
+		params.Match = user.ReservedExact
+	}
+	if !params.Match.IsValid() {
+		return response.BadRequestError(e, "match must be exact or contains")
+	}
+	w := &user.ReservedWord{
+		Word:      params.Word,
+		Match:     params.Match,
+		CreatedBy: auth.MustGetUserID(e),
+	}
+	if err := ac.userService.AddReservedWord(w); err != nil {
+		return response.ServerError(e, err, "")
+	}
+	recordEvent(ac.auditService, e, 0, audit.ActionUsernameReserve, w.Word, audit.Payload{"match": w.Match})
+	return response.Success(e)
+}
+
+// @Title Release reserved username
+// @Description Lets users take usernames the word blocked.
+// @Param word path string true "reserved word"
+// @Success 200 success
+// @Route /admin/reserved-usernames/{word} [delete]
+func (ac *AdminController) RemoveReservedWord(e echo.Context) error {
+	word := e.Param("word")
+	if err := ac.userService.RemoveReservedWord(word); err != nil {
+		return adminError(e, err)
+	}
+	recordEvent(ac.auditService, e, 0, audit.ActionUsernameUnreserve, word, nil)
+	return response.Success(e)
+}
@@ -0,0 +1,218 @@
+package v1
+
+import (
+	"errors"
+	"shogun/config"
+	"shogun/internal/api/middleware/auth"
+	"shogun/internal/api/response"
+	"shogun/internal/model/apikey"
+	"shogun/internal/model/audit"
+	"shogun/internal/services/apikeystore"
+	"shogun/internal/services/auditstore"
+	"shogun/internal/services/userstore"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+const apiKeyUsageMaxDays = 90
+
+type ApiKeyController struct {
+	apiKeyService apikeystore.Store
+	userService   userstore.Store
+	auditService  auditstore.Recorder
+}
+
+func NewApiKeyController(ks apikeystore.Store, us userstore.Store, ar auditstore.Recorder) *ApiKeyController {
+	return &ApiKeyController{
+		apiKeyService: ks,
+		userService:   us,
+		auditService:  ar,
+	}
+}
+
+type createApiKeyParams struct {
+	Name   string        `json:"name"`
+	Scopes apikey.Scopes `json:"scopes"`
+	//only admins can set it, users get the default
+	HourQuota int64 `json:"hourly_quota"`
+}
+
+// @Enum createApiKeyResponse
+type createApiKeyResponse struct {
+	apikey.Key
+	Secret string `json:"secret"`
+}
+
+// create - makes a key for userID, the secret is returned once and never stored
+func (kc *ApiKeyController) create(e echo.Context, userID int64, params *createApiKeyParams) error {
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" || utf8.RuneCountInString(params.Name) > apikey.NameMaxLength {
+		return response.BadRequestError(e, "name is invalid")
+	}
+	if !params.Scopes.IsValid() {
+		return response.BadRequestError(e, "scopes are invalid")
+	}
+	count, err := kc.apiKeyService.CountActiveForUser(userID)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	if count >= config.Cfg.ApiKeyMaxPerUser {
+		return response.OtherErrors(e, response.ErrorApiKeyLimit, "too many api keys, revoke one first")
+	}
+
+	secret, prefix, hash, err := apikey.GenerateSecret()
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	k := apikey.New()
+	k.UserID = userID
+	k.Name = params.Name
+	k.Prefix = prefix
+	k.SecretHash = hash
+	k.Scopes = params.Scopes
+	k.HourQuota = params.HourQuota
+	if k.HourQuota <= 0 {
+		k.HourQuota = config.Cfg.ApiKeyHourlyQuota
+	}
+	k.CreatedBy = auth.MustGetUserID(e)
+	if err = kc.apiKeyService.Create(k); err != nil {
+		return response.ServerError(e, err, "")
+	}
+	recordEvent(kc.auditService, e, userID, audit.ActionApiKeyCreate, k.Prefix, audit.Payload{
+		"key_id":       strconv.FormatInt(k.ID, 10),
+		"scopes":       k.Scopes,
+		"hourly_quota": k.HourQuota,
+	})
+	return response.JSON(e, createApiKeyResponse{Key: *k, Secret: secret})
+}
+
+// revoke - revokes the key, userID 0 when an admin revokes someone else's key
+func (kc *ApiKeyController) revoke(e echo.Context, userID int64) error {
+	id, err := strconv.ParseInt(e.Param("id"), 10, 64)
+	if err != nil {
+		return response.BadRequestError(e, "invalid id")
+	}
+	k, err := kc.apiKeyService.Revoke(id, userID)
+	if err != nil {
+		if errors.Is(err, apikeystore.ErrorKeyNotFound) {
+			return response.OtherErrors(e, response.ErrorApiKeyInvalid, "api key not found")
+		}
+		return response.ServerError(e, err, "")
+	}
+	recordEvent(kc.auditService, e, k.UserID, audit.ActionApiKeyRevoke, k.Prefix, audit.Payload{
+		"key_id": strconv.FormatInt(k.ID, 10),
+	})
+	return response.Success(e)
+}
+
+// @Title List API keys
+// @Description My api keys, revoked ones included. Secrets are never shown again after creating a key.
+// @Success 200 {array} apikey.Key
+// @Route /user/api-keys [get]
+func (kc *ApiKeyController) List(e echo.Context) error {
+	keys, err := kc.apiKeyService.GetForUser(auth.MustGetUserID(e))
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, keys)
+}
+
+// @Title Create API key
+// @Description Creates a key for server to server calls, send it in the X-Api-Key header.
+// @Description The secret is only in this response, store it right away.
+// @Param body body createApiKeyParams true "name and scopes: token:read, user:resolve"
+// @Success 200 {object} createApiKeyResponse
+// @Route /user/api-keys [post]
+func (kc *ApiKeyController) Create(e echo.Context) error {
+	params := &createApiKeyParams{}
+	if err := e.Bind(params); err != nil {
+		return response.BadRequestError(e, "")
+	}
+	params.HourQuota = 0
+	return kc.create(e, auth.MustGetUserID(e), params)
+}
+
+// @Title Revoke API key
+// @Param id path string true "Key id"
+// @Success 200 success
+// @Route /user/api-keys/{id} [delete]
+func (kc *ApiKeyController) Revoke(e echo.Context) error {
+	return kc.revoke(e, auth.MustGetUserID(e))
+}
+
+// @Title API key usage
+// @Description Requests made with the key per day, newest first.
+// @Param id path string true "Key id"
+// @Param days query int false "Days back, max 90"
+// @Success 200 {array} apikey.Usage
+// @Route /user/api-keys/{id}/usage [get]
+func (kc *ApiKeyController) Usage(e echo.Context) error {
+	id, err := strconv.ParseInt(e.Param("id"), 10, 64)
+	if err != nil {
+		return response.BadRequestError(e, "invalid id")
+	}
+	k, err := kc.apiKeyService.Get(id)
+	if err != nil && !errors.Is(err, apikeystore.ErrorKeyNotFound) {
+		return response.ServerError(e, err, "")
+	}
+	if k == nil || k.UserID != auth.MustGetUserID(e) {
+		return response.OtherErrors(e, response.ErrorApiKeyInvalid, "api key not found")
+	}
+	days, _ := strconv.Atoi(e.QueryParam("days"))
+	if days <= 0 || days > apiKeyUsageMaxDays {
+		days = apiKeyUsageMaxDays
+	}
+	usage, err := kc.apiKeyService.GetUsage(id, days)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, usage)
+}
+
+// @Title List user API keys
+// @Param id path string true "User id"
+// @Success 200 {array} apikey.Key
+// @Route /admin/users/{id}/api-keys [get]
+func (kc *ApiKeyController) AdminList(e echo.Context) error {
+	userID, err := targetUserID(e)
+	if err != nil {
+		return response.BadRequestError(e, "invalid id")
+	}
+	keys, err := kc.apiKeyService.GetForUser(userID)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, keys)
+}
+
+// @Title Create user API key
+// @Description Creates a key for a partner's account, hourly_quota overrides the default quota.
+// @Param id path string true "User id"
+// @Param body body createApiKeyParams true "name, scopes and quota"
+// @Success 200 {object} createApiKeyResponse
+// @Route /admin/users/{id}/api-keys [post]
+func (kc *ApiKeyController) AdminCreate(e echo.Context) error {
+	userID, err := targetUserID(e)
+	if err != nil {
+		return response.BadRequestError(e, "invalid id")
+	}
+	if _, err = kc.userService.GetSimpleByID(userID); err != nil {
+		return adminError(e, err)
+	}
+	params := &createApiKeyParams{}
+	if err = e.Bind(params); err != nil || params.HourQuota < 0 {
+		return response.BadRequestError(e, "")
+	}
+	return kc.create(e, userID, params)
+}
+
+// @Title Revoke any API key
+// @Param id path string true "Key id"
+// @Success 200 success
+// @Route /admin/api-keys/{id} [delete]
+func (kc *ApiKeyController) AdminRevoke(e echo.Context) error {
+	return kc.revoke(e, 0)
+}
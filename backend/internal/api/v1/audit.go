@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"shogun/internal/api/middleware/auth"
+	"shogun/internal/api/response"
+	"shogun/internal/model/audit"
+	"shogun/internal/services/auditstore"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	auditPageDefault = 50
+	auditPageMax     = 200
+)
+
+// recordEvent - writes a security event to the history of userID, the logged-in user is the actor,
+// a failed write is logged and never fails the request
+func recordEvent(rec auditstore.Recorder, e echo.Context, userID int64, action audit.Action, target string, payload audit.Payload) {
+	ev := audit.New()
+	ev.UserID = userID
+	ev.ActorID = userID
+	if actorID, ok := auth.GetUserID(e); ok {
+		ev.ActorID = actorID
+	}
+	ev.Action = action
+	ev.Target = target
+	ev.IP = e.RealIP()
+	ev.UserAgent = e.Request().UserAgent()
+	ev.Payload = payload
+	if err := rec.Record(ev); err != nil {
+		log.Err(err).Int64("user", userID).Str("action", string(action)).Msg("failed to record audit event")
+	}
+}
+
+// auditPage - before and limit query params of audit listings
+func auditPage(e echo.Context) (int64, int) {
+	before, _ := strconv.ParseInt(e.QueryParam("before"), 10, 64)
+	limit, _ := strconv.Atoi(e.QueryParam("limit"))
+	if limit <= 0 {
+		limit = auditPageDefault
+	}
+	return before, min(limit, auditPageMax)
+}
+
+// @Title Security log
+// @Description Security history of my account, newest first. Pass the id of the last event as before to get the next page.
+// @Param before query string false "Event id cursor"
+// @Param limit query int false "Page size, max 200"
+// @Success 200 {array} audit.Event
+// @Route /user/security-log [get]
+func (uc *UserController) SecurityLog(e echo.Context) error {
+	before, limit := auditPage(e)
+	events, err := uc.auditService.GetForUser(auth.MustGetUserID(e), before, limit)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, events)
+}
+
+// @Title Query audit log
+// @Description Security events of any user, filtered by user, actor or action, newest first.
+// @Param user_id query string false "Whose history"
+// @Param actor_id query string false "Who did it"
+// @Param action query string false "Event action"
+// @Param before query string false "Event id cursor"
+// @Param limit query int false "Page size, max 200"
+// @Success 200 {array} audit.Event
+// @Route /admin/audit [get]
+func (ac *AdminController) QueryAudit(e echo.Context) error {
+	f := audit.Filter{}
+	f.UserID, _ = strconv.ParseInt(e.QueryParam("user_id"), 10, 64)
+	f.ActorID, _ = strconv.ParseInt(e.QueryParam("actor_id"), 10, 64)
+	f.Action = audit.Action(e.QueryParam("action"))
+	f.Before, f.Limit = auditPage(e)
+	events, err := ac.auditService.Query(f)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, events)
+}
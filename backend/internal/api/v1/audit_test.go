@@ -0,0 +1,54 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"shogun/internal/model/audit"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRecorder struct {
+	events []*audit.Event
+	err    error
+}
+
+func (f *fakeRecorder) Record(ev *audit.Event) error {
+	//same as the sql store
+	ev.Truncate()
+	f.events = append(f.events, ev)
+	return f.err
+}
+
+func TestRecordEvent(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/9/suspend", nil)
+	req.RemoteAddr = "203.0.113.7:1234"
+	req.Header.Set("User-Agent", strings.Repeat("x", 255)+"日本")
+	e := echo.New().NewContext(req, httptest.NewRecorder())
+	e.Set("access-token-userid", int64(1))
+
+	rec := &fakeRecorder{}
+	recordEvent(rec, e, 9, audit.ActionUserSuspend, "", audit.Payload{"reason": "spam"})
+	assert.Len(t, rec.events, 1)
+	ev := rec.events[0]
+	assert.Equal(t, int64(9), ev.UserID)
+	assert.Equal(t, int64(1), ev.ActorID)
+	assert.Equal(t, "203.0.113.7", ev.IP)
+	assert.True(t, utf8.ValidString(ev.UserAgent))
+	assert.Equal(t, strings.Repeat("x", 255)+"日", ev.UserAgent)
+
+	//without a logged-in actor the user acted on their own account
+	e = echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/v1/auth/login", nil), httptest.NewRecorder())
+	recordEvent(rec, e, 5, audit.ActionLogin, "", nil)
+	assert.Equal(t, int64(5), rec.events[1].ActorID)
+
+	//a failed write never fails the request
+	rec.err = errors.New("db down")
+	recordEvent(rec, e, 5, audit.ActionLogout, "", nil)
+	assert.Len(t, rec.events, 3)
+}
@@ -2,8 +2,17 @@ package v1
 
 import (
 	"shogun/internal/services/accountstore"
+	"shogun/internal/services/auditstore"
+	"shogun/internal/services/challengestore"
+	"shogun/internal/services/pairingstore"
 	"shogun/internal/services/prefstore"
+	"shogun/internal/services/rolestore"
+	"shogun/internal/services/sessionrevoke"
+	"shogun/internal/services/sessionstore"
 	"shogun/internal/services/siglocker"
+	"shogun/internal/services/signupguard"
+	"shogun/internal/services/suspension"
+	"shogun/internal/services/userinfosync"
 	"shogun/internal/services/userstore"
 
 	"github.com/jmoiron/sqlx"
@@ -13,7 +22,16 @@ type AuthController struct {
 	accountService    accountstore.Store
 	userService       userstore.Store
 	preferenceService prefstore.Store
+	sessionService    sessionstore.Store
+	challengeService  challengestore.Store
 	signatureChecker  siglocker.UseChecker
+	sessionRevoker    sessionrevoke.Checker
+	roleService       rolestore.Store
+	suspension        suspension.Checker
+	userSync          userinfosync.Service
+	auditService      auditstore.Recorder
+	pairingService    pairingstore.Store
+	signupGuard       *signupguard.Guard
 	db                *sqlx.DB
 }
 
@@ -22,13 +40,31 @@ func NewAuthController(
 	accountService accountstore.Store,
 	userService userstore.Store,
 	preferenceService prefstore.Store,
+	sessionService sessionstore.Store,
+	challengeService challengestore.Store,
 	signatureChecker siglocker.UseChecker,
+	sessionRevoker sessionrevoke.Checker,
+	roleService rolestore.Store,
+	suspension suspension.Checker,
+	userSync userinfosync.Service,
+	auditService auditstore.Recorder,
+	pairingService pairingstore.Store,
+	signupGuard *signupguard.Guard,
 ) *AuthController {
 	return &AuthController{
 		accountService:    accountService,
 		userService:       userService,
 		preferenceService: preferenceService,
+		sessionService:    sessionService,
+		challengeService:  challengeService,
 		signatureChecker:  signatureChecker,
+		sessionRevoker:    sessionRevoker,
+		roleService:       roleService,
+		suspension:        suspension,
+		userSync:          userSync,
+		auditService:      auditService,
+		pairingService:    pairingService,
+		signupGuard:       signupGuard,
 		db:                db,
 	}
 }
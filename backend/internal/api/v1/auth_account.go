@@ -2,24 +2,34 @@ package v1
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
+	"shogun/internal/api/middleware/auth"
 	"shogun/internal/api/response"
 	"shogun/internal/model/account"
+	"shogun/internal/model/audit"
 	"shogun/internal/model/chain"
+	"shogun/internal/model/challenge"
+	"shogun/internal/model/user"
+	"shogun/internal/services/accountstore"
 	"shogun/internal/services/signverifier"
-	"time"
 
-	"github.com/gagliardetto/solana-go"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
 )
 
+var (
+	errorNotPrimaryAccount = errors.New("not the primary account")
+	errorInvalidSignature  = errors.New("invalid signature")
+)
+
 // @Enum addAccountParams
 type addAccountParams struct {
 	Accounts  []addAccountItem `json:"accounts"`
-	PublicKey solana.PublicKey `json:"public_key"`
-	Timestamp int64            `json:"timestamp"`
+	Address   string           `json:"address"`
+	PublicKey string           `json:"public_key"` //deprecated, solana only, use address
+	Chain     chain.Chain      `json:"chain"`
+	Nonce     string           `json:"nonce"`
 }
 
 // @Enum addAccountItem
@@ -32,14 +42,80 @@ type addAccountItem struct {
 	LinkSignature string `json:"link_signature"`
 }
 
+// @Enum changeAccountParams
+type changeAccountParams struct {
+	//Primary is the current login key, it signs the request
+	Primary      string      `json:"primary"`
+	PrimaryChain chain.Chain `json:"primary_chain"`
+	Address      string      `json:"address"`
+	Chain        chain.Chain `json:"chain"`
+	//Signature is by the affected address over the unlink or set primary message
+	Signature string `json:"signature"`
+	Nonce     string `json:"nonce"`
+}
+
+// verifyPrimaryRequest - makes sure the body was signed by the primary account of the logged-in user
+// over the challenge message for the action
+func (ac *AuthController) verifyPrimaryRequest(e echo.Context, body []byte, address string, c chain.Chain, nonce string, action challenge.Action) (*account.Account, error) {
+	userID := auth.MustGetUserID(e)
+	primary, err := ac.accountService.GetPrimary(userID)
+	if err != nil {
+		return nil, err
+	}
+	if primary.Address != address || primary.Chain != c {
+		return nil, errorNotPrimaryAccount
+	}
+	ch, err := ac.consumeChallenge(nonce, action, address, c)
+	if err != nil {
+		return nil, err
+	}
+	if !signverifier.Verify(c, ch.MessageWithPayload(body), address, e.QueryParam("signature")) {
+		return nil, errorInvalidSignature
+	}
+	return primary, nil
+}
+
+// accountError - maps account and signature errors to api responses
+func accountError(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, errorNotPrimaryAccount):
+		return response.OtherErrors(e, response.ErrorNotPrimaryAccount, "request must be signed by the primary account")
+	case errors.Is(err, errorInvalidSignature):
+		return response.BadRequestError(e, "invalid signature")
+	case errors.Is(err, accountstore.ErrorAccountNotFound):
+		return response.BadRequestError(e, "account not found")
+	case errors.Is(err, accountstore.ErrorAccountAlreadyLinked):
+		return response.OtherErrors(e, response.ErrorAccountAlreadyLinked, "account already linked")
+	case errors.Is(err, accountstore.ErrorCannotUnlinkPrimary):
+		return response.OtherErrors(e, response.ErrorCannotUnlinkPrimary, "primary account can't be unlinked")
+	case errors.Is(err, accountstore.ErrorLastAccount):
+		return response.OtherErrors(e, response.ErrorLastAccount, "user must keep at least one account")
+	case errors.Is(err, errorChallengeInvalid), errors.Is(err, errorChallengeMismatch):
+		return challengeError(e, err)
+	default:
+		return response.ServerError(e, err, "")
+	}
+}
+
+// accountsChanged - tells every server the owner of these addresses changed
+func (ac *AuthController) accountsChanged(addresses ...user.Address) {
+	for _, a := range addresses {
+		if err := ac.userSync.AccountChanged(a); err != nil {
+			log.Err(err).Str("address", a.Address).Msg("failed to broadcast account change")
+		}
+	}
+}
+
 // @Title Add Accounts
 // @Description Link the provided accounts with the user
 // @Param body body addAccountParams true "accounts to associate with the user"
+// @Param signature query string true "signature by the primary account over the link challenge message"
 // @Success 200 success
-// @Route /auth/accounts [post]
+// @Route /auth/link [post]
 
 // LinkAccounts - links given accounts to the user
-// endpoint requires access-token and body signature signed by the given solana key
+// endpoint requires access-token and a signature by the primary account over the
+// link challenge message bound to the request body
 func (ac *AuthController) LinkAccounts(e echo.Context) error {
 	originalBody, err := io.ReadAll(e.Request().Body)
 	if err != nil {
@@ -50,32 +126,27 @@ func (ac *AuthController) LinkAccounts(e echo.Context) error {
 	if err != nil {
 		return response.BadRequestError(e, "invalid request body")
 	}
-	if time.Since(time.UnixMilli(params.Timestamp)).Seconds() > 15 {
-		return response.BadRequestError(e, "request expired")
+	if params.Address == "" {
+		params.Address = params.PublicKey
 	}
-	if params.PublicKey.IsZero() {
-		return response.BadRequestError(e, "public key is required")
+	if params.Chain == "" {
+		params.Chain = chain.Solana
 	}
-
-	solanaSignature, err := solana.SignatureFromBase58(e.QueryParam("signature"))
-	if err != nil {
-		return response.BadRequestError(e, "invalid signature")
+	if params.Address == "" {
+		return response.BadRequestError(e, "address is required")
 	}
-
-	verifyMessage := []byte(fmt.Sprintf("/auth/link%s", string(originalBody)))
-	isBodyReal := params.PublicKey.Verify(verifyMessage, solanaSignature)
-	if !isBodyReal {
-		return response.BadRequestError(e, "invalid signature")
+	if len(params.Accounts) == 0 {
+		return response.BadRequestError(e, "accounts are required")
 	}
 
-	owner, err := ac.accountService.GetUserIDForAddress(params.PublicKey.String(), chain.Solana)
+	primary, err := ac.verifyPrimaryRequest(e, originalBody, params.Address, params.Chain, params.Nonce, challenge.ActionLink)
 	if err != nil {
-		return response.ServerError(e, err, "")
+		return accountError(e, err)
 	}
 
-	//the signed message is scary to stop people from signing it
 	//verify all the keys have been signed by the owner
-	proofMessage := fmt.Sprintf("I agree to give all my money now and in the future to %s", params.PublicKey.String())
+	//both messages carry the nonce of the challenge consumed above, so they can't be replayed
+	proofMessage := account.ProofMessage(primary.Address, params.Nonce)
 	for _, a := range params.Accounts {
 		if !a.Chain.IsSupported() {
 			return response.BadRequestError(e, "unsupported chain")
@@ -84,26 +155,118 @@ func (ac *AuthController) LinkAccounts(e echo.Context) error {
 		if !ok {
 			return response.BadRequestError(e, "invalid signature")
 		}
-		linkMessage := fmt.Sprintf("I agree to give all my money now and in the future to %s", a.Address)
-		ok = signverifier.Verify(chain.Solana, linkMessage, params.PublicKey.String(), a.LinkSignature)
+		ok = signverifier.Verify(primary.Chain, account.LinkMessage(a.Address, params.Nonce), primary.Address, a.LinkSignature)
 		if !ok {
 			return response.BadRequestError(e, "invalid signature")
 		}
 	}
 
-	for i := range params.Accounts {
-		a := params.Accounts[i]
-		acc := &account.Account{
-			Address:   a.Address,
-			UserID:    owner,
-			Chain:     a.Chain,
-			Signature: a.LinkSignature,
-		}
-		err = ac.accountService.CreateAccount(acc)
-		if err != nil {
-			log.Err(err).Msg("failed to create account")
-		}
+	accounts := make([]account.Account, 0, len(params.Accounts))
+	changed := make([]user.Address, 0, len(params.Accounts))
+	for _, a := range params.Accounts {
+		accounts = append(accounts, account.Account{
+			Address:        a.Address,
+			Chain:          a.Chain,
+			Signature:      a.LinkSignature,
+			ProofSignature: a.ProofSignature,
+			LinkedBy:       primary.Address,
+			LinkedByChain:  primary.Chain,
+			LinkNonce:      params.Nonce,
+		})
+		changed = append(changed, user.Address{Address: a.Address, Chain: a.Chain})
+	}
+	err = ac.accountService.LinkAccounts(primary.UserID, accounts)
+	if err != nil {
+		return accountError(e, err)
+	}
+	ac.accountsChanged(changed...)
+	for _, a := range changed {
+		recordEvent(ac.auditService, e, primary.UserID, audit.ActionAccountLink, a.Address, audit.Payload{"chain": a.Chain})
 	}
 
 	return response.Success(e)
 }
+
+// readChangeAccountParams - reads and checks the body shared by unlink and set primary
+func readChangeAccountParams(e echo.Context) ([]byte, *changeAccountParams, error) {
+	originalBody, err := io.ReadAll(e.Request().Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	params := &changeAccountParams{}
+	if err = json.Unmarshal(originalBody, params); err != nil {
+		return nil, nil, err
+	}
+	if params.PrimaryChain == "" {
+		params.PrimaryChain = chain.Solana
+	}
+	if params.Primary == "" || params.Address == "" || !params.Chain.IsSupported() {
+		return nil, nil, errors.New("primary, address and chain are required")
+	}
+	return originalBody, params, nil
+}
+
+// @Title Unlink Account
+// @Description Removes a linked account, signed by both the primary account and the removed account. The primary account can't be removed.
+// @Param body body changeAccountParams true "account to remove"
+// @Param signature query string true "signature by the primary account over the unlink challenge message"
+// @Success 200 success
+// @Route /auth/unlink [post]
+func (ac *AuthController) UnlinkAccount(e echo.Context) error {
+	body, params, err := readChangeAccountParams(e)
+	if err != nil {
+		return response.BadRequestError(e, err.Error())
+	}
+	primary, err := ac.verifyPrimaryRequest(e, body, params.Primary, params.PrimaryChain, params.Nonce, challenge.ActionUnlink)
+	if err != nil {
+		return accountError(e, err)
+	}
+	message := account.UnlinkMessage(params.Address, params.Chain, params.Nonce)
+	if !signverifier.Verify(params.Chain, message, params.Address, params.Signature) {
+		return response.BadRequestError(e, "invalid signature")
+	}
+
+	err = ac.accountService.UnlinkAccount(primary.UserID, params.Address, params.Chain)
+	if err != nil {
+		return accountError(e, err)
+	}
+	ac.accountsChanged(user.Address{Address: params.Address, Chain: params.Chain})
+	recordEvent(ac.auditService, e, primary.UserID, audit.ActionAccountUnlink, params.Address, audit.Payload{"chain": params.Chain})
+	return response.Success(e)
+}
+
+// @Title Set Primary Account
+// @Description Moves the login key to another linked account, signed by both the current and the new primary account.
+// @Param body body changeAccountParams true "account that becomes the login key"
+// @Param signature query string true "signature by the current primary account over the set_primary challenge message"
+// @Success 200 success
+// @Route /auth/primary [post]
+func (ac *AuthController) SetPrimaryAccount(e echo.Context) error {
+	body, params, err := readChangeAccountParams(e)
+	if err != nil {
+		return response.BadRequestError(e, err.Error())
+	}
+	primary, err := ac.verifyPrimaryRequest(e, body, params.Primary, params.PrimaryChain, params.Nonce, challenge.ActionSetPrimary)
+	if err != nil {
+		return accountError(e, err)
+	}
+	message := account.SetPrimaryMessage(params.Address, params.Chain, params.Nonce)
+	if !signverifier.Verify(params.Chain, message, params.Address, params.Signature) {
+		return response.BadRequestError(e, "invalid signature")
+	}
+
+	err = ac.accountService.SetPrimary(primary.UserID, params.Address, params.Chain)
+	if err != nil {
+		return accountError(e, err)
+	}
+	ac.accountsChanged(
+		user.Address{Address: primary.Address, Chain: primary.Chain},
+		user.Address{Address: params.Address, Chain: params.Chain},
+	)
+	recordEvent(ac.auditService, e, primary.UserID, audit.ActionPrimaryChange, params.Address, audit.Payload{
+		"chain":            params.Chain,
+		"previous_address": primary.Address,
+		"previous_chain":   primary.Chain,
+	})
+	return response.Success(e)
+}
@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"errors"
+	"shogun/config"
+	"shogun/internal/api/response"
+	"shogun/internal/model/chain"
+	"shogun/internal/model/challenge"
+	"shogun/internal/services/challengestore"
+	"shogun/internal/services/signverifier"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errorChallengeInvalid  = errors.New("challenge invalid")
+	errorChallengeMismatch = errors.New("challenge does not match request")
+)
+
+type challengeQueryParams struct {
+	Address string           `query:"address"`
+	Chain   chain.Chain      `query:"chain"`
+	Action  challenge.Action `query:"action"`
+}
+
+// @Enum challengeResponse
+type challengeResponse struct {
+	Nonce     string `json:"nonce"`
+	Message   string `json:"message"`
+	ExpiresAt int64  `json:"expires_at"`
+	//login only, a new user has to send a proof of work over the nonce with this many leading zero bits
+	PowDifficulty int `json:"pow_difficulty,omitempty"`
+}
+
+// @Title Auth Challenge
+// @Description Issues a single use nonce and the message the wallet has to sign for login, exists and link.
+// @Param address query string true "address that will sign the message"
+// @Param chain query string false "chain of the address, defaults to solana"
+// @Param action query string true "login, exists or link"
+// @Success 200 {object} challengeResponse
+// @Route /auth/challenge [get]
+func (ac *AuthController) ChallengeGET(e echo.Context) error {
+	queryParams := &challengeQueryParams{}
+	if err := e.Bind(queryParams); err != nil {
+		return response.BadRequestError(e, "")
+	}
+	if queryParams.Chain == "" {
+		queryParams.Chain = chain.Solana
+	}
+	if !queryParams.Chain.IsSupported() {
+		return response.BadRequestError(e, "unsupported chain")
+	}
+	if !queryParams.Action.IsValid() {
+		return response.BadRequestError(e, "invalid action")
+	}
+	if !signverifier.IsValidAddress(queryParams.Chain, queryParams.Address) {
+		return response.BadRequestError(e, "invalid address")
+	}
+
+	c := challenge.New(
+		config.Cfg.AuthDomain,
+		config.Cfg.AuthURI,
+		queryParams.Address,
+		queryParams.Chain,
+		queryParams.Action,
+		time.Duration(config.Cfg.ChallengeTTLSeconds)*time.Second,
+	)
+	if err := ac.challengeService.Issue(c); err != nil {
+		return response.ServerError(e, err, "")
+	}
+	res := challengeResponse{
+		Nonce:     c.Nonce,
+		Message:   c.Message(),
+		ExpiresAt: c.ExpiresAt.Unix(),
+	}
+	if c.Action == challenge.ActionLogin {
+		res.PowDifficulty = ac.signupGuard.Difficulty()
+	}
+	return response.JSON(e, res)
+}
+
+// consumeChallenge - uses up the nonce and makes sure it was issued for this exact action and signer
+func (ac *AuthController) consumeChallenge(nonce string, action challenge.Action, address string, c chain.Chain) (*challenge.Challenge, error) {
+	return ac.checkChallenge(ac.challengeService.Consume, nonce, action, address, c)
+}
+
+// peekChallenge - consumeChallenge without using up the nonce, so a request with a bad
+// signature can't spoil the challenge for the real signer
+func (ac *AuthController) peekChallenge(nonce string, action challenge.Action, address string, c chain.Chain) (*challenge.Challenge, error) {
+	return ac.checkChallenge(ac.challengeService.Get, nonce, action, address, c)
+}
+
+func (ac *AuthController) checkChallenge(load func(string) (*challenge.Challenge, error), nonce string, action challenge.Action, address string, c chain.Chain) (*challenge.Challenge, error) {
+	if nonce == "" {
+		return nil, errorChallengeInvalid
+	}
+	ch, err := load(nonce)
+	if err != nil {
+		if errors.Is(err, challengestore.ErrorChallengeNotFound) {
+			return nil, errorChallengeInvalid
+		}
+		return nil, err
+	}
+	if ch.Domain != config.Cfg.AuthDomain || ch.Action != action || ch.Address != address || ch.Chain != c {
+		return nil, errorChallengeMismatch
+	}
+	return ch, nil
+}
+
+// challengeError - maps consumeChallenge errors to api responses
+func challengeError(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, errorChallengeInvalid):
+		return response.BadRequestError(e, "challenge expired")
+	case errors.Is(err, errorChallengeMismatch):
+		return response.BadRequestError(e, "challenge mismatch")
+	default:
+		return response.ServerError(e, err, "")
+	}
+}
@@ -2,20 +2,20 @@ package v1
 
 import (
 	"errors"
-	"fmt"
 	"shogun/internal/api/response"
 	"shogun/internal/model/chain"
+	"shogun/internal/model/challenge"
 	"shogun/internal/model/user"
+	"shogun/internal/services/signverifier"
 	"shogun/internal/services/userstore"
-	"time"
 
-	"github.com/gagliardetto/solana-go"
 	"github.com/labstack/echo/v4"
 )
 
 type existsQueryParams struct {
-	Timestamp int64  `query:"timestamp"`
-	Signature string `query:"signature"`
+	Chain     chain.Chain `query:"chain"`
+	Nonce     string      `query:"nonce"`
+	Signature string      `query:"signature"`
 }
 
 type existsResponse struct {
@@ -24,7 +24,7 @@ type existsResponse struct {
 }
 
 func (ac *AuthController) Exists(e echo.Context) error {
-	//user must sign /auth/exists/address?timestamp=<timestamp> with their private key
+	//user must sign the message of an exists challenge with their private key
 	//to check if they exist in the system, reason is some users turn off search on their
 	//accounts and when importing key back to the app, we need to check if they exist
 	address := e.Param("address")
@@ -33,26 +33,30 @@ func (ac *AuthController) Exists(e echo.Context) error {
 	if err != nil {
 		return response.BadRequestError(e, "")
 	}
-	signature, err := solana.SignatureFromBase58(queryParams.Signature)
-	if err != nil {
+	if queryParams.Chain == "" {
+		queryParams.Chain = chain.Solana
+	}
+	if !queryParams.Chain.IsSupported() {
+		return response.BadRequestError(e, "unsupported chain")
+	}
+	if queryParams.Signature == "" {
 		return response.BadRequestError(e, "signature is required")
 	}
-	publicKey, err := solana.PublicKeyFromBase58(address)
-	if err != nil {
+	if !signverifier.IsValidAddress(queryParams.Chain, address) {
 		return response.BadRequestError(e, "invalid address")
 	}
 
-	if time.Since(time.UnixMilli(queryParams.Timestamp)).Seconds() > 15 {
-		return response.BadRequestError(e, "request expired")
+	ch, err := ac.consumeChallenge(queryParams.Nonce, challenge.ActionExists, address, queryParams.Chain)
+	if err != nil {
+		return challengeError(e, err)
 	}
-
-	msg := []byte(fmt.Sprintf("/auth/exists/%s?timestamp=%d", address, queryParams.Timestamp))
-	isVerified := publicKey.Verify(msg, signature)
+	isVerified := signverifier.Verify(queryParams.Chain, ch.Message(), address, queryParams.Signature)
 	if !isVerified {
 		return response.BadRequestError(e, "signature verification failed")
 	}
 
-	userSimple, err := ac.userService.GetSimpleOwnerOfAddress(publicKey.String(), chain.Solana)
+	//no visibility check, the signature proves the caller owns the address and users always see themselves
+	userSimple, err := ac.userService.GetSimpleOwnerOfAddress(address, queryParams.Chain)
 	if err != nil {
 		if errors.Is(err, userstore.ErrorUserNotFound) {
 			return response.JSON(e, &existsResponse{Exists: false})
@@ -2,38 +2,54 @@ package v1
 
 import (
 	"errors"
-	"fmt"
+	"shogun/internal/api/middleware/auth"
 	"shogun/internal/api/response"
 	"shogun/internal/model/account"
+	"shogun/internal/model/audit"
 	"shogun/internal/model/chain"
+	"shogun/internal/model/challenge"
 	"shogun/internal/model/preferences"
+	"shogun/internal/model/session"
 	"shogun/internal/model/user"
-	"shogun/internal/security/accesstoken"
 	"shogun/internal/services/accountstore"
+	"shogun/internal/services/siglocker"
+	"shogun/internal/services/signupguard"
+	"shogun/internal/services/signverifier"
 	"shogun/internal/utils/randomname"
 	"time"
 
-	"github.com/gagliardetto/solana-go"
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
 )
 
 type loginQueryParams struct {
-	PublicKey string `query:"public_key"`
-	Timestamp int64  `query:"timestamp"`
-	Signature string `query:"signature"`
+	Address   string      `query:"address"`
+	PublicKey string      `query:"public_key"` //deprecated, solana only, use address
+	Chain     chain.Chain `query:"chain"`
+	Nonce     string      `query:"nonce"`
+	Signature string      `query:"signature"`
+	Proof     string      `query:"pow"` //only for new users when the challenge asks for proof of work
+
+	DeviceName string           `query:"device_name"`
+	Platform   session.Platform `query:"platform"`
 }
 
 // @Enum loginSuccessResponse
 type loginSuccessResponse struct {
-	AccessToken string `json:"access_token"`
-	IsNewUser   bool   `json:"is_new_user,omitempty"`
-	ExpiresIn   int64  `json:"expires_in"`
+	tokensResponse
+	IsNewUser bool `json:"is_new_user,omitempty"`
 }
 
 // @Title User Login
-// @Description Authenticates user based on the provided timestamp and signature, along with the required Solana public key in the headers.
-// @Param timestamp query int64 true "Timestamp of the login request"
-// @Param signature query string true "Signature for the login request"
+// @Description Authenticates user with a signature over the message of a login challenge from /auth/challenge.
+// @Param address query string true "Address that signed the challenge"
+// @Param chain query string false "Chain of the address, any supported chain, defaults to solana"
+// @Param nonce query string true "Nonce of the login challenge"
+// @Param signature query string true "Signature of the challenge message"
+// @Param pow query string false "Proof of work over the nonce, needed to create a user when the challenge has pow_difficulty"
+// @Param device_name query string false "Name of the device shown in the sessions list"
+// @Param platform query string false "ios, android, desktop or web"
+// @Param X-Session-Mode header string false "cookie for a browser session, the tokens are set as HttpOnly cookies"
 // @Success 200 {object} loginSuccessResponse "User is successfully authenticated"
 // @Route /auth/login [get]
 func (ac *AuthController) LoginGET(e echo.Context) error {
@@ -42,37 +58,72 @@ func (ac *AuthController) LoginGET(e echo.Context) error {
 	if err != nil {
 		return response.BadRequestError(e, "")
 	}
-	if time.Since(time.UnixMilli(queryParams.Timestamp)).Seconds() > 15 {
-		return response.BadRequestError(e, "request expired")
+	if queryParams.Chain == "" {
+		queryParams.Chain = chain.Solana
 	}
-	userPublicKey, err := solana.PublicKeyFromBase58(queryParams.PublicKey)
-	if err != nil {
-		return response.BadRequestError(e, "solana public key is required")
+	if queryParams.Address == "" {
+		queryParams.Address = queryParams.PublicKey
 	}
-	signature, err := solana.SignatureFromBase58(queryParams.Signature)
-	if err != nil {
+	if !queryParams.Chain.IsSupported() {
+		return response.BadRequestError(e, "unsupported chain")
+	}
+	if !signverifier.IsValidAddress(queryParams.Chain, queryParams.Address) {
+		return response.BadRequestError(e, "invalid address")
+	}
+	if queryParams.Signature == "" {
 		return response.BadRequestError(e, "signature is required")
 	}
-	if ac.signatureChecker.IsSignatureUsed(queryParams.Signature) {
-		return response.BadRequestError(e, "signature expired")
+	//nothing is used up until the signature checks out
+	ch, err := ac.peekChallenge(queryParams.Nonce, challenge.ActionLogin, queryParams.Address, queryParams.Chain)
+	if err != nil {
+		return challengeError(e, err)
 	}
-	//add it right away not to be used again, push it to nats too for other servers
-	ac.signatureChecker.Notify(queryParams.Signature)
-
-	signedMessage := []byte(fmt.Sprintf("/auth/login/%s?timestamp=%d", queryParams.PublicKey, queryParams.Timestamp))
-	isValid := userPublicKey.Verify(signedMessage, signature)
+	isValid := signverifier.Verify(queryParams.Chain, ch.Message(), queryParams.Address, queryParams.Signature)
 	if !isValid {
 		return response.UnauthorizedError(e)
 	}
+	if err = ac.signatureChecker.Use(queryParams.Signature); err != nil {
+		if errors.Is(err, siglocker.ErrorSignatureUsed) {
+			return response.BadRequestError(e, "signature expired")
+		}
+		return response.ServerError(e, err, "")
+	}
+	if _, err = ac.consumeChallenge(queryParams.Nonce, challenge.ActionLogin, queryParams.Address, queryParams.Chain); err != nil {
+		return challengeError(e, err)
+	}
 
 	isNewUser := false
-	ownerID, err := ac.accountService.GetUserIDForAddress(userPublicKey.String(), chain.Solana)
+	var ownerID int64
+	acc, err := ac.accountService.GetAccount(queryParams.Address, queryParams.Chain)
 	if err != nil && !errors.Is(err, accountstore.ErrorAccountNotFound) {
 		return response.ServerError(e, err, "")
 	}
 	if errors.Is(err, accountstore.ErrorAccountNotFound) {
 		isNewUser = true
-		ownerID, err = ac.createNewUser(userPublicKey)
+		ownerID, err = ac.createNewUser(queryParams.Address, queryParams.Chain, signupguard.Attempt{
+			IP:    e.RealIP(),
+			Nonce: queryParams.Nonce,
+			Proof: queryParams.Proof,
+		})
+	} else if !acc.IsPrimary {
+		//only the primary account is the login key, linked accounts can't log in
+		return response.OtherErrors(e, response.ErrorNotPrimaryAccount, "address is not the login key")
+	} else {
+		ownerID = acc.UserID
+		//logging in during the grace period keeps the account
+		if _, err = ac.userService.CancelDeletion(ownerID); err != nil {
+			return response.ServerError(e, err, "")
+		}
+	}
+	if errors.Is(err, signupguard.ErrorThrottled) || errors.Is(err, signupguard.ErrorProofRequired) {
+		recordEvent(ac.auditService, e, 0, audit.ActionSignupBlocked, queryParams.Address, audit.Payload{
+			"chain":  queryParams.Chain,
+			"reason": err.Error(),
+		})
+		if errors.Is(err, signupguard.ErrorProofRequired) {
+			return response.OtherErrors(e, response.ErrorProofOfWorkRequired, "proof of work required, get a new challenge and solve it")
+		}
+		return response.OtherErrors(e, response.ErrorSignupThrottled, "too many sign ups from this network, try again later")
 	}
 	if err != nil {
 		return response.ServerError(e, err, "")
@@ -80,16 +131,44 @@ func (ac *AuthController) LoginGET(e echo.Context) error {
 	if ownerID == 0 {
 		return response.ServerError(e, errors.New("owner id is zero"), "")
 	}
+	if ac.suspension.IsSuspended(ownerID) {
+		return response.OtherErrors(e, response.ErrorUserSuspended, "user suspended")
+	}
 
-	token, expiresAt := accesstoken.GenerateTokenForUser(ownerID)
+	action := audit.ActionLogin
+	if isNewUser {
+		action = audit.ActionSignUp
+	}
+	recordEvent(ac.auditService, e, ownerID, action, queryParams.Address, audit.Payload{
+		"chain":       queryParams.Chain,
+		"device_name": queryParams.DeviceName,
+		"platform":    queryParams.Platform,
+	})
+
+	tokens, err := ac.startSession(ownerID, session.Device{
+		DeviceName: queryParams.DeviceName,
+		Platform:   queryParams.Platform,
+		IP:         e.RealIP(),
+		UserAgent:  e.Request().UserAgent(),
+	})
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	if auth.WantsCookies(e) {
+		sendAsCookies(e, tokens)
+	}
 	return response.JSON(e, loginSuccessResponse{
-		AccessToken: token,
-		ExpiresIn:   expiresAt - time.Now().Unix(), // in seconds
-		IsNewUser:   isNewUser,
+		tokensResponse: *tokens,
+		IsNewUser:      isNewUser,
 	})
 }
 
-func (ac *AuthController) createNewUser(solanaPublicKey solana.PublicKey) (int64, error) {
+// createNewUser - creates the user with the login address as its first account, on any supported chain,
+// the attempt has to pass the sign up guard first so scripts can't mint unlimited users
+func (ac *AuthController) createNewUser(address string, c chain.Chain, attempt signupguard.Attempt) (int64, error) {
+	if err := ac.signupGuard.Check(attempt); err != nil {
+		return 0, err
+	}
 	tx, err := ac.db.Beginx()
 	if err != nil {
 		return 0, err
@@ -113,8 +192,9 @@ func (ac *AuthController) createNewUser(solanaPublicKey solana.PublicKey) (int64
 		return 0, errors.New("user id is zero")
 	}
 	a := account.New()
-	a.Address = solanaPublicKey.String()
-	a.Chain = chain.Solana
+	a.Address = address
+	a.Chain = c
+	a.IsPrimary = true
 	a.UserID = u.ID
 	a.CreatedAt = time.Now()
 	err = ac.accountService.CreateAccountNoCommit(tx, a)
@@ -134,6 +214,10 @@ func (ac *AuthController) createNewUser(solanaPublicKey solana.PublicKey) (int64
 	if err != nil {
 		return 0, err
 	}
+	//new usernames have to reach the search indexes like renamed ones
+	if err = ac.userSync.Update(u.ID, user.Updatable{Username: &u.Username}); err != nil {
+		log.Err(err).Int64("user_id", u.ID).Msg("failed to broadcast new user")
+	}
 
 	return u.ID, nil
 }
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"shogun/config"
+	"shogun/internal/api/response"
+	"shogun/internal/model/account"
+	"shogun/internal/model/chain"
+	"shogun/internal/model/challenge"
+	"shogun/internal/services/accountstore"
+	"shogun/internal/services/challengestore"
+	"shogun/internal/services/siglocker"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeChallenges struct {
+	challengestore.Store
+	issued map[string]*challenge.Challenge
+}
+
+func (f *fakeChallenges) Get(nonce string) (*challenge.Challenge, error) {
+	ch, ok := f.issued[nonce]
+	if !ok {
+		return nil, challengestore.ErrorChallengeNotFound
+	}
+	return ch, nil
+}
+
+func (f *fakeChallenges) Consume(nonce string) (*challenge.Challenge, error) {
+	ch, err := f.Get(nonce)
+	delete(f.issued, nonce)
+	return ch, err
+}
+
+var errorAccountLookup = errors.New("account lookup failed")
+
+type failingAccounts struct {
+	accountstore.Store
+}
+
+func (failingAccounts) GetAccount(string, chain.Chain) (*account.Account, error) {
+	return nil, errorAccountLookup
+}
+
+func TestLoginGET_BadSignatureUsesNothing(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	address := solana.PublicKeyFromBytes(public).String()
+	ch := challenge.New(config.Cfg.AuthDomain, "", address, chain.Solana, challenge.ActionLogin, time.Minute)
+	challenges := &fakeChallenges{issued: map[string]*challenge.Challenge{ch.Nonce: ch}}
+	signatures := siglocker.NewMemory(time.Minute)
+	//the account lookup fails so the login stops right after the challenge is used up
+	ac := NewAuthController(nil, failingAccounts{}, nil, nil, nil, challenges, signatures, nil, nil, nil, nil, nil, nil, nil)
+
+	login := func(signature []byte) response.Status {
+		q := url.Values{}
+		q.Set("address", address)
+		q.Set("nonce", ch.Nonce)
+		q.Set("signature", solana.SignatureFromBytes(signature).String())
+		rec := httptest.NewRecorder()
+		e := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/v1/auth/login?"+q.Encode(), nil), rec)
+		_ = ac.LoginGET(e)
+		res := &response.Response{}
+		_ = json.Unmarshal(rec.Body.Bytes(), res)
+		return res.Status
+	}
+
+	_, otherPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	bad := ed25519.Sign(otherPrivate, []byte(ch.Message()))
+	assert.Equal(t, response.StatusUnauthorized, login(bad))
+	assert.Contains(t, challenges.issued, ch.Nonce)
+	assert.Nil(t, signatures.Use(solana.SignatureFromBytes(bad).String()))
+
+	good := ed25519.Sign(private, []byte(ch.Message()))
+	assert.Equal(t, response.StatusServerError, login(good))
+	assert.NotContains(t, challenges.issued, ch.Nonce)
+	assert.ErrorIs(t, signatures.Use(solana.SignatureFromBytes(good).String()), siglocker.ErrorSignatureUsed)
+}
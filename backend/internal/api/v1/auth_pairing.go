@@ -0,0 +1,263 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"shogun/config"
+	"shogun/internal/api/middleware/auth"
+	"shogun/internal/api/response"
+	"shogun/internal/model/audit"
+	"shogun/internal/model/pairing"
+	"shogun/internal/model/session"
+	"shogun/internal/services/pairingstore"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const pairingSecretHeader = "X-Pairing-Secret"
+
+var pairingUpgrader = websocket.Upgrader{
+	//the desktop app isn't served from our origin, the pairing secret is what protects the socket
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// @Enum startPairingParams
+type startPairingParams struct {
+	DeviceName string           `json:"device_name"`
+	Platform   session.Platform `json:"platform"`
+}
+
+// @Enum startPairingResponse
+type startPairingResponse struct {
+	Code      string    `json:"code"`
+	Secret    string    `json:"secret"`
+	URI       string    `json:"uri"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// @Enum pairingInfoResponse
+type pairingInfoResponse struct {
+	Code      string         `json:"code"`
+	Status    pairing.Status `json:"status"`
+	Device    session.Device `json:"device"`
+	CreatedAt time.Time      `json:"created_at"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// @Enum pairingWaitResponse
+type pairingWaitResponse struct {
+	Status pairing.Status  `json:"status"`
+	Tokens *tokensResponse `json:"tokens,omitempty"`
+}
+
+func pairingError(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, pairingstore.ErrorPairingNotFound), errors.Is(err, pairingstore.ErrorPairingNotApproved):
+		return response.OtherErrors(e, response.ErrorPairingNotFound, "pairing not found or expired")
+	case errors.Is(err, pairingstore.ErrorPairingResolved):
+		return response.OtherErrors(e, response.ErrorPairingResolved, "pairing already answered")
+	default:
+		return response.ServerError(e, err, "")
+	}
+}
+
+// @Title Start Pairing
+// @Description Desktop asks for a pairing code and shows uri as a QR. Keep the secret, it's needed to receive the session.
+// @Param body body startPairingParams true "the desktop device"
+// @Success 200 {object} startPairingResponse
+// @Route /auth/pair [post]
+func (ac *AuthController) StartPairing(e echo.Context) error {
+	params := &startPairingParams{}
+	if err := e.Bind(params); err != nil {
+		return response.BadRequestError(e, "")
+	}
+	if params.Platform == session.PlatformUnknown {
+		params.Platform = session.PlatformDesktop
+	}
+	device := session.Device{
+		DeviceName: params.DeviceName,
+		Platform:   params.Platform,
+		IP:         e.RealIP(),
+		UserAgent:  e.Request().UserAgent(),
+	}
+	device.Truncate()
+	p, secret := pairing.New(device, time.Duration(config.Cfg.PairingTTLSeconds)*time.Second)
+	if err := ac.pairingService.Create(p); err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, startPairingResponse{
+		Code:      p.Code,
+		Secret:    secret,
+		URI:       p.URI(),
+		ExpiresAt: p.ExpiresAt,
+	})
+}
+
+// @Title Get Pairing
+// @Description The phone shows which device is asking before the user approves it.
+// @Param code path string true "Code from the QR"
+// @Success 200 {object} pairingInfoResponse
+// @Route /auth/pair/{code} [get]
+func (ac *AuthController) GetPairing(e echo.Context) error {
+	p, err := ac.pairingService.Get(e.Param("code"))
+	if err != nil {
+		return pairingError(e, err)
+	}
+	if p.IsResolved() {
+		return pairingError(e, pairingstore.ErrorPairingResolved)
+	}
+	return response.JSON(e, pairingInfoResponse{
+		Code:      p.Code,
+		Status:    p.Status,
+		Device:    p.Device,
+		CreatedAt: p.CreatedAt,
+		ExpiresAt: p.ExpiresAt,
+	})
+}
+
+// @Title Approve Pairing
+// @Description Signs the desktop in to my account, the request must be signed by one of my linked accounts.
+// @Param code path string true "Code from the QR"
+// @Success 200 success
+// @Route /auth/pair/{code}/approve [post]
+func (ac *AuthController) ApprovePairing(e echo.Context) error {
+	userID := auth.MustGetUserID(e)
+	p, err := ac.pairingService.Resolve(e.Param("code"), userID, true)
+	if err != nil {
+		return pairingError(e, err)
+	}
+	recordEvent(ac.auditService, e, userID, audit.ActionDevicePair, p.Device.DeviceName, audit.Payload{
+		"platform": p.Device.Platform,
+		"ip":       p.Device.IP,
+	})
+	return response.Success(e)
+}
+
+// @Title Reject Pairing
+// @Param code path string true "Code from the QR"
+// @Success 200 success
+// @Route /auth/pair/{code}/reject [post]
+func (ac *AuthController) RejectPairing(e echo.Context) error {
+	_, err := ac.pairingService.Resolve(e.Param("code"), auth.MustGetUserID(e), false)
+	if err != nil {
+		return pairingError(e, err)
+	}
+	return response.Success(e)
+}
+
+// pairingFor - the pairing of the code, only for the desktop holding its secret
+func (ac *AuthController) pairingFor(code, secret string) (*pairing.Pairing, error) {
+	p, err := ac.pairingService.Get(code)
+	if err != nil {
+		return nil, err
+	}
+	if !p.IsSecretValid(secret) {
+		return nil, pairingstore.ErrorPairingNotFound
+	}
+	return p, nil
+}
+
+// waitPairing - waits for the phone and once approved claims the pairing and starts the desktop session
+func (ac *AuthController) waitPairing(e echo.Context, ctx context.Context, code string) (*pairingWaitResponse, error) {
+	p, err := ac.pairingService.Wait(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != pairing.StatusApproved {
+		return &pairingWaitResponse{Status: p.Status}, nil
+	}
+	p, err = ac.pairingService.Claim(code)
+	if err != nil {
+		return nil, err
+	}
+	if ac.suspension.IsSuspended(p.UserID) {
+		return &pairingWaitResponse{Status: pairing.StatusRejected}, nil
+	}
+	tokens, err := ac.startSession(p.UserID, p.Device)
+	if err != nil {
+		return nil, err
+	}
+	recordEvent(ac.auditService, e, p.UserID, audit.ActionLogin, p.Device.DeviceName, audit.Payload{
+		"method":   "pairing",
+		"platform": p.Device.Platform,
+	})
+	return &pairingWaitResponse{Status: pairing.StatusClaimed, Tokens: tokens}, nil
+}
+
+// @Title Wait For Pairing
+// @Description Long-poll from the desktop, answers once the phone approves or rejects, or with pending after a while so the desktop asks again.
+// @Description Approved pairings come back as claimed with the session tokens, they are only handed out once.
+// @Param code path string true "Pairing code"
+// @Param X-Pairing-Secret header string true "Secret from start pairing"
+// @Success 200 {object} pairingWaitResponse
+// @Route /auth/pair/{code}/wait [get]
+func (ac *AuthController) WaitPairing(e echo.Context) error {
+	code := e.Param("code")
+	if _, err := ac.pairingFor(code, e.Request().Header.Get(pairingSecretHeader)); err != nil {
+		return pairingError(e, err)
+	}
+	ctx, cancel := context.WithTimeout(e.Request().Context(), time.Duration(config.Cfg.PairingWaitSeconds)*time.Second)
+	defer cancel()
+	res, err := ac.waitPairing(e, ctx, code)
+	if err != nil {
+		return pairingError(e, err)
+	}
+	return response.JSON(e, res)
+}
+
+// @Title Pairing Socket
+// @Description WebSocket alternative to wait, one message is sent once the phone answers or the code expires, then the socket closes.
+// @Param code path string true "Pairing code"
+// @Param secret query string true "Secret from start pairing, sockets can't always set headers"
+// @Success 101 {object} pairingWaitResponse
+// @Route /auth/pair/{code}/ws [get]
+func (ac *AuthController) PairingSocket(e echo.Context) error {
+	code := e.Param("code")
+	secret := e.Request().Header.Get(pairingSecretHeader)
+	if secret == "" {
+		secret = e.QueryParam("secret")
+	}
+	p, err := ac.pairingFor(code, secret)
+	if err != nil {
+		return pairingError(e, err)
+	}
+	conn, err := pairingUpgrader.Upgrade(e.Response(), e.Request(), nil)
+	if err != nil {
+		//the upgrader already wrote the error response
+		return nil
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithDeadline(context.Background(), p.ExpiresAt)
+	defer cancel()
+	go func() {
+		//we never expect messages, reading only notices the desktop going away
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	res, err := ac.waitPairing(e, ctx, code)
+	if err != nil {
+		if !errors.Is(err, pairingstore.ErrorPairingNotFound) && !errors.Is(err, pairingstore.ErrorPairingNotApproved) {
+			log.Err(err).Str("code", code).Msg("pairing socket failed")
+		}
+		res = &pairingWaitResponse{Status: pairing.StatusRejected}
+	}
+	if ctx.Err() != nil && res.Status == pairing.StatusPending {
+		//expired, the desktop should start over with a new code
+		res.Status = pairing.StatusRejected
+	}
+	if err = conn.WriteJSON(res); err != nil {
+		log.Err(err).Str("code", code).Msg("failed to write pairing result")
+	}
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	return nil
+}
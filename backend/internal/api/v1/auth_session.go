@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"errors"
+	"shogun/internal/api/middleware/auth"
+	"shogun/internal/api/response"
+	"shogun/internal/model/audit"
+	"shogun/internal/model/session"
+	"shogun/internal/security/accesstoken"
+	"shogun/internal/services/sessionstore"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// @Enum refreshParams
+type refreshParams struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// @Enum tokensResponse
+type tokensResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+	//browser sessions only, the tokens are in cookies and this goes in the X-CSRF-Token header
+	CSRFToken string `json:"csrf_token,omitempty"`
+}
+
+// sendAsCookies - browser sessions get the tokens as HttpOnly cookies instead of in the body
+func sendAsCookies(e echo.Context, tokens *tokensResponse) {
+	tokens.CSRFToken = auth.SetSessionCookies(e,
+		tokens.AccessToken, time.Duration(tokens.ExpiresIn)*time.Second,
+		tokens.RefreshToken, time.Duration(tokens.RefreshExpiresIn)*time.Second)
+	tokens.AccessToken = ""
+	tokens.RefreshToken = ""
+}
+
+// startSession - creates a new session for the user and returns its first token pair
+func (ac *AuthController) startSession(userID int64, device session.Device) (*tokensResponse, error) {
+	refreshToken, refreshHash := accesstoken.GenerateRefreshToken()
+	ses := session.New()
+	ses.UserID = userID
+	ses.Device = device
+	ses.RefreshTokenHash = refreshHash
+	ses.ExpiresAt = time.Now().Add(accesstoken.RefreshDuration())
+	if err := ac.sessionService.Create(ses); err != nil {
+		return nil, err
+	}
+	if ses.ID == 0 {
+		return nil, errors.New("session id is zero")
+	}
+	return ac.makeTokensResponse(ses, refreshToken)
+}
+
+// makeTokensResponse - the role is read on every issue so granted or removed roles
+// show up in the next access token
+func (ac *AuthController) makeTokensResponse(ses *session.Session, refreshToken string) (*tokensResponse, error) {
+	r, err := ac.roleService.Get(ses.UserID)
+	if err != nil {
+		return nil, err
+	}
+	token, expiresAt := accesstoken.GenerateTokenForUser(ses.UserID, ses.ID, r)
+	return &tokensResponse{
+		AccessToken:      token,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        expiresAt - time.Now().Unix(), // in seconds
+		RefreshExpiresIn: int64(time.Until(ses.ExpiresAt).Seconds()),
+	}, nil
+}
+
+// @Title Refresh Tokens
+// @Description Exchanges a refresh token for a new access token and a new refresh token, the old refresh token stops working.
+// @Description Browser sessions leave the body empty, the refresh cookie is used and the X-CSRF-Token header is required.
+// @Param body body refreshParams false "refresh token from login or the last refresh"
+// @Success 200 {object} tokensResponse
+// @Route /auth/refresh [post]
+func (ac *AuthController) Refresh(e echo.Context) error {
+	params := &refreshParams{}
+	if err := e.Bind(params); err != nil {
+		return response.BadRequestError(e, "")
+	}
+	fromCookie := false
+	if params.RefreshToken == "" {
+		params.RefreshToken = auth.RefreshTokenFromCookie(e)
+		fromCookie = params.RefreshToken != ""
+		if fromCookie && !auth.ValidCSRF(e) {
+			return response.OtherErrors(e, response.ErrorCSRFInvalid, "csrf token missing or invalid")
+		}
+	}
+	if params.RefreshToken == "" {
+		return response.BadRequestError(e, "refresh token is required")
+	}
+
+	refreshToken, refreshHash := accesstoken.GenerateRefreshToken()
+	ses, err := ac.sessionService.Rotate(
+		accesstoken.HashRefreshToken(params.RefreshToken),
+		refreshHash,
+		time.Now().Add(accesstoken.RefreshDuration()),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sessionstore.ErrorRefreshTokenReused):
+			//the session is already revoked in the store, stop its access tokens too
+			if err = ac.sessionRevoker.Revoke(ses.ID); err != nil {
+				log.Err(err).Int64("session", ses.ID).Msg("failed to broadcast revoked session")
+			}
+			auth.ClearSessionCookies(e)
+			return response.OtherErrors(e, response.ErrorSessionRevoked, "session revoked")
+		case errors.Is(err, sessionstore.ErrorSessionNotFound), errors.Is(err, sessionstore.ErrorRefreshTokenExpired):
+			auth.ClearSessionCookies(e)
+			return response.OtherErrors(e, response.ErrorRefreshTokenInvalid, "refresh token invalid")
+		default:
+			return response.ServerError(e, err, "")
+		}
+	}
+	if ac.suspension.IsSuspended(ses.UserID) {
+		return response.OtherErrors(e, response.ErrorUserSuspended, "user suspended")
+	}
+	tokens, err := ac.makeTokensResponse(ses, refreshToken)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	if fromCookie || auth.WantsCookies(e) {
+		sendAsCookies(e, tokens)
+	}
+	return response.JSON(e, tokens)
+}
+
+// @Title Logout
+// @Description Revokes the current session, both its access token and refresh token stop working.
+// @Success 200 success
+// @Route /auth/logout [post]
+func (ac *AuthController) Logout(e echo.Context) error {
+	sessionID := auth.MustGetSessionID(e)
+	if err := ac.sessionService.Revoke(sessionID); err != nil {
+		return response.ServerError(e, err, "")
+	}
+	if err := ac.sessionRevoker.Revoke(sessionID); err != nil {
+		return response.ServerError(e, err, "")
+	}
+	recordEvent(ac.auditService, e, auth.MustGetUserID(e), audit.ActionLogout, strconv.FormatInt(sessionID, 10), nil)
+	auth.ClearSessionCookies(e)
+	return response.Success(e)
+}
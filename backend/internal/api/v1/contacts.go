@@ -0,0 +1,232 @@
+package v1
+
+import (
+	"errors"
+	"shogun/internal/api/middleware/auth"
+	"shogun/internal/api/response"
+	"shogun/internal/model/contact"
+	"shogun/internal/model/user"
+	"shogun/internal/services/contactstore"
+	"shogun/internal/services/usercache"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	contactPageDefault = 50
+	contactPageMax     = 100
+)
+
+type ContactController struct {
+	contactService contactstore.Store
+	userCache      usercache.SimpleCache
+}
+
+func NewContactController(cs contactstore.Store, uc usercache.SimpleCache) *ContactController {
+	return &ContactController{
+		contactService: cs,
+		userCache:      uc,
+	}
+}
+
+// @Enum contactItem
+type contactItem struct {
+	user.Simple
+	Status contact.Status `json:"status"`
+	//Since is when the request was sent or accepted
+	Since time.Time `json:"since"`
+}
+
+// @Enum contactsPage
+type contactsPage struct {
+	Contacts []contactItem `json:"contacts"`
+	//Next goes in before to get the next page, empty on the last page
+	Next string `json:"next,omitempty"`
+}
+
+// @Enum requestResponse
+type requestResponse struct {
+	Status contact.Status `json:"status"`
+}
+
+func contactError(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, contactstore.ErrorRequestNotFound):
+		return response.OtherErrors(e, response.ErrorContactRequestNotFound, "contact request not found")
+	case errors.Is(err, contactstore.ErrorNotContacts):
+		return response.OtherErrors(e, response.ErrorNotContacts, "not contacts")
+	case errors.Is(err, contactstore.ErrorTooManyPending):
+		return response.OtherErrors(e, response.ErrorTooManyContactRequests, "too many pending contact requests, wait for answers or cancel some")
+	case errors.Is(err, contactstore.ErrorSelf):
+		return response.BadRequestError(e, "can't add yourself as a contact")
+	case errors.Is(err, usercache.ErrorUserNotFound):
+		return response.OtherErrors(e, response.ErrorUserNotFound, "user not found")
+	}
+	return response.ServerError(e, err, "")
+}
+
+func pageParams(e echo.Context) (int64, int) {
+	before, _ := strconv.ParseInt(e.QueryParam("before"), 10, 64)
+	limit, _ := strconv.Atoi(e.QueryParam("limit"))
+	if limit <= 0 {
+		limit = contactPageDefault
+	}
+	return max(before, 0), min(limit, contactPageMax)
+}
+
+func otherID(e echo.Context) (int64, bool) {
+	id, err := strconv.ParseInt(e.Param("id"), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// page - turns the rows into users seen by userID, users the cache can't find anymore are left out
+func (cc *ContactController) page(userID int64, contacts []contact.Contact, limit int) contactsPage {
+	res := contactsPage{Contacts: make([]contactItem, 0, len(contacts))}
+	for _, c := range contacts {
+		u, err := cc.userCache.GetByID(c.Other(userID))
+		if err != nil {
+			if !errors.Is(err, usercache.ErrorUserNotFound) {
+				log.Err(err).Int64("user_id", c.Other(userID)).Msg("contact user lookup failed")
+			}
+			continue
+		}
+		res.Contacts = append(res.Contacts, contactItem{Simple: *u, Status: c.Status, Since: c.UpdatedAt})
+	}
+	if len(contacts) == limit {
+		res.Next = strconv.FormatInt(contacts[len(contacts)-1].ID, 10)
+	}
+	return res
+}
+
+// @Title List contacts
+// @Description My contacts, newest first by when I sent the request or accepted theirs.
+// @Param before query string false "next from the previous page"
+// @Param limit query int false "page size, max 100"
+// @Success 200 {object} contactsPage
+// @Route /contacts [get]
+func (cc *ContactController) List(e echo.Context) error {
+	userID := auth.MustGetUserID(e)
+	before, limit := pageParams(e)
+	contacts, err := cc.contactService.List(userID, before, limit)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, cc.page(userID, contacts, limit))
+}
+
+// @Title List contact requests
+// @Description Pending requests sent to me, or sent by me with direction outgoing, newest first.
+// @Param direction query string false "incoming or outgoing, incoming by default"
+// @Param before query string false "next from the previous page"
+// @Param limit query int false "page size, max 100"
+// @Success 200 {object} contactsPage
+// @Route /contacts/requests [get]
+func (cc *ContactController) Requests(e echo.Context) error {
+	userID := auth.MustGetUserID(e)
+	before, limit := pageParams(e)
+	var contacts []contact.Contact
+	var err error
+	switch e.QueryParam("direction") {
+	case "", "incoming":
+		contacts, err = cc.contactService.Incoming(userID, before, limit)
+	case "outgoing":
+		contacts, err = cc.contactService.Outgoing(userID, before, limit)
+	default:
+		return response.BadRequestError(e, "direction must be incoming or outgoing")
+	}
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, cc.page(userID, contacts, limit))
+}
+
+// @Title Request contact
+// @Description Asks the user to become a contact. If they already asked me we become contacts right away. At most 100 of my requests can wait for an answer.
+// @Param id path string true "user id"
+// @Success 200 {object} requestResponse
+// @Route /contacts/{id}/request [post]
+func (cc *ContactController) Request(e echo.Context) error {
+	contactID, ok := otherID(e)
+	if !ok {
+		return response.BadRequestError(e, "invalid user id")
+	}
+	if _, err := cc.userCache.GetByID(contactID); err != nil {
+		return contactError(e, err)
+	}
+	status, err := cc.contactService.Request(auth.MustGetUserID(e), contactID)
+	if err != nil {
+		return contactError(e, err)
+	}
+	return response.JSON(e, requestResponse{Status: status})
+}
+
+// @Title Accept contact request
+// @Param id path string true "id of the user who sent the request"
+// @Success 200 success
+// @Route /contacts/{id}/accept [post]
+func (cc *ContactController) Accept(e echo.Context) error {
+	requesterID, ok := otherID(e)
+	if !ok {
+		return response.BadRequestError(e, "invalid user id")
+	}
+	if err := cc.contactService.Accept(auth.MustGetUserID(e), requesterID); err != nil {
+		return contactError(e, err)
+	}
+	return response.Success(e)
+}
+
+// @Title Decline contact request
+// @Description The requester isn't told, they can ask again.
+// @Param id path string true "id of the user who sent the request"
+// @Success 200 success
+// @Route /contacts/{id}/decline [post]
+func (cc *ContactController) Decline(e echo.Context) error {
+	requesterID, ok := otherID(e)
+	if !ok {
+		return response.BadRequestError(e, "invalid user id")
+	}
+	if err := cc.contactService.Decline(auth.MustGetUserID(e), requesterID); err != nil {
+		return contactError(e, err)
+	}
+	return response.Success(e)
+}
+
+// @Title Remove contact
+// @Description Removes the contact for both of us, also cancels a request I sent.
+// @Param id path string true "user id"
+// @Success 200 success
+// @Route /contacts/{id} [delete]
+func (cc *ContactController) Remove(e echo.Context) error {
+	id, ok := otherID(e)
+	if !ok {
+		return response.BadRequestError(e, "invalid user id")
+	}
+	if err := cc.contactService.Remove(auth.MustGetUserID(e), id); err != nil {
+		return contactError(e, err)
+	}
+	return response.Success(e)
+}
+
+// @Title Mutual contacts
+// @Description My contacts who are contacts of the user too.
+// @Param id path string true "user id"
+// @Param before query string false "next from the previous page"
+// @Param limit query int false "page size, max 100"
+// @Success 200 {object} contactsPage
+// @Route /contacts/{id}/mutual [get]
+func (cc *ContactController) Mutual(e echo.Context) error {
+	id, ok := otherID(e)
+	if !ok {
+		return response.BadRequestError(e, "invalid user id")
+	}
+	userID := auth.MustGetUserID(e)
+	before, limit := pageParams(e)
+	contacts, err := cc.contactService.Mutual(userID, id, before, limit)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, cc.page(userID, contacts, limit))
+}
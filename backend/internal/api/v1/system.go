@@ -1,8 +1,10 @@
 package v1
 
 import (
+	"net/http"
 	"shogun/config"
 	"shogun/internal/api/response"
+	"shogun/internal/security/accesstoken"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -41,3 +43,13 @@ func (sc SystemController) SystemGET(e echo.Context) error {
 	sc.data.Timestamp = time.Now().UnixMilli()
 	return response.JSON(e, sc.data)
 }
+
+// @Title JWKS
+// @Description Public keys access tokens are signed with, in the standard JWK Set format so other services can verify tokens on their own.
+// @Success 200 object accesstoken.JWKS
+// @Route /.well-known/jwks.json [get]
+func (sc SystemController) JWKSGET(e echo.Context) error {
+	//not wrapped in our response envelope, jwt libraries expect the plain document
+	e.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return e.JSON(http.StatusOK, accesstoken.PublicKeys())
+}
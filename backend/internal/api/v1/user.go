@@ -8,13 +8,19 @@ import (
 	"shogun/internal/api/middleware/auth"
 	"shogun/internal/api/response"
 	"shogun/internal/model/account"
+	"shogun/internal/model/audit"
 	"shogun/internal/model/image"
 	"shogun/internal/model/user"
 	"shogun/internal/services/accountstore"
+	"shogun/internal/services/auditstore"
+	"shogun/internal/services/autocomplete"
 	"shogun/internal/services/fileuploader"
 	"shogun/internal/services/prefstore"
 	"shogun/internal/services/usercache"
+	"shogun/internal/services/usereraser"
+	"shogun/internal/services/userinfosync"
 	"shogun/internal/services/userstore"
+	"shogun/internal/services/visibility"
 	"shogun/internal/utils/blurhash"
 	"time"
 
@@ -30,6 +36,11 @@ type UserController struct {
 	r2UploaderService fileuploader.Service
 	preferenceService prefstore.Store
 	userCache         usercache.SimpleCache
+	eraser            *usereraser.Eraser
+	auditService      auditstore.Store
+	userSync          userinfosync.Service
+	autocomplete      autocomplete.Autocomplete
+	visibility        *visibility.Policy
 }
 
 func NewUserController(
@@ -38,6 +49,11 @@ func NewUserController(
 	rs fileuploader.Service,
 	ps prefstore.Store,
 	uc usercache.SimpleCache,
+	ue *usereraser.Eraser,
+	aus auditstore.Store,
+	sync userinfosync.Service,
+	ac autocomplete.Autocomplete,
+	vp *visibility.Policy,
 ) *UserController {
 
 	return &UserController{
@@ -46,6 +62,11 @@ func NewUserController(
 		r2UploaderService: rs,
 		preferenceService: ps,
 		userCache:         uc,
+		eraser:            ue,
+		auditService:      aus,
+		userSync:          sync,
+		autocomplete:      ac,
+		visibility:        vp,
 	}
 }
 
@@ -78,8 +99,33 @@ func (uc *UserController) GetMe(e echo.Context) error {
 	return response.JSON(e, res)
 }
 
-// TODO check username make sure we can go back to previous used one
-// TODO locked previous used one for a few days so that no one else can use them
+// usernameError - maps errors of username changes to api responses
+func usernameError(e echo.Context, err error) error {
+	switch {
+	case errors.Is(err, userstore.ErrorDuplicateUsername):
+		return response.OtherErrors(e, response.ErrorUsernameTaken, "username taken")
+	case errors.Is(err, userstore.ErrorUsernameReserved):
+		return response.OtherErrors(e, response.ErrorUsernameReserved, "username is reserved")
+	case errors.Is(err, userstore.ErrorUsernameQuarantined):
+		return response.OtherErrors(e, response.ErrorUsernameQuarantined, "username was released recently and can only be taken back by its previous owner")
+	default:
+		return response.ServerError(e, err, "")
+	}
+}
+
+// @Title Username history
+// @Description Usernames I gave up, newest first. Until reclaimable_until only I can take one back.
+// @Success 200 {array} user.PastUsername
+// @Route /user/username-history [get]
+func (uc *UserController) UsernameHistory(e echo.Context) error {
+	history, err := uc.userService.GetUsernameHistory(auth.MustGetUserID(e))
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, history)
+}
+
+// Update - a released username stays in quarantine for a while, only its previous owner can take it back
 func (uc *UserController) Update(e echo.Context) error {
 	userID := auth.MustGetUserID(e)
 
@@ -113,9 +159,23 @@ func (uc *UserController) Update(e echo.Context) error {
 
 	err := uc.userService.Update(userID, updatable)
 	if err != nil {
-		return response.ServerError(e, err, "")
+		return usernameError(e, err)
+	}
+	if updatable.Username != nil || updatable.Name != nil {
+		if err = uc.userSync.Update(userID, *updatable); err != nil {
+			log.Err(err).Int64("user_id", userID).Msg("failed to broadcast user update")
+		}
 	}
 
+	if updatable.Username != nil {
+		recordEvent(uc.auditService, e, userID, audit.ActionUsernameChange, *updatable.Username, nil)
+	}
+	if updatable.Name != nil || updatable.Bio != nil {
+		recordEvent(uc.auditService, e, userID, audit.ActionProfileUpdate, "", audit.Payload{
+			"name": updatable.Name != nil,
+			"bio":  updatable.Bio != nil,
+		})
+	}
 	return response.Success(e)
 }
 
@@ -166,6 +226,7 @@ func (uc *UserController) UpdateThumbnail(e echo.Context) error {
 	if err != nil {
 		return response.ServerError(e, err, "")
 	}
+	recordEvent(uc.auditService, e, userID, audit.ActionThumbnailUpload, location, nil)
 
 	go func() {
 		hash, err := blurhash.GetFromUrl(imageMeta.Uri + "?w=50&h=50&o=png")
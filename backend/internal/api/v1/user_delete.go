@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"shogun/config"
+	"shogun/internal/api/middleware/auth"
+	"shogun/internal/api/response"
+	"shogun/internal/model/audit"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// @Enum deleteMeResponse
+type deleteMeResponse struct {
+	DeleteAfter time.Time `json:"delete_after"`
+}
+
+// @Title Delete my account
+// @Description Deletes the account with its linked accounts, preferences and thumbnails. The request must be signed by the primary account.
+// @Description Every session is signed out, with a grace period logging in again before delete_after cancels the deletion.
+// @Success 200 {object} deleteMeResponse
+// @Route /user/me [delete]
+func (uc *UserController) Delete(e echo.Context) error {
+	userID := auth.MustGetUserID(e)
+	grace := time.Duration(config.Cfg.AccountDeletionGraceDays) * 24 * time.Hour
+	deleteAfter, err := uc.eraser.Schedule(userID, grace)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	recordEvent(uc.auditService, e, userID, audit.ActionDeleteRequest, "", audit.Payload{"delete_after": deleteAfter})
+	return response.JSON(e, deleteMeResponse{DeleteAfter: deleteAfter})
+}
@@ -3,6 +3,7 @@ package v1
 import (
 	"shogun/internal/api/middleware/auth"
 	"shogun/internal/api/response"
+	"shogun/internal/model/audit"
 	"shogun/internal/model/preferences"
 
 	"github.com/labstack/echo/v4"
@@ -33,5 +34,6 @@ func (uc *UserController) UpdatePreferences(e echo.Context) error {
 	if err != nil {
 		return response.ServerError(e, err, "")
 	}
+	recordEvent(uc.auditService, e, userID, audit.ActionPreferencesUpdate, "", nil)
 	return response.Success(e)
 }
@@ -1,28 +1,64 @@
 package v1
 
 import (
+	"errors"
+	"shogun/internal/api/middleware/auth"
 	"shogun/internal/api/response"
 	"shogun/internal/model/account"
+	"shogun/internal/model/attestation"
 	"shogun/internal/model/chain"
 	"shogun/internal/model/user"
+	"shogun/internal/security/accesstoken"
+	"shogun/internal/services/userstore"
+	"shogun/internal/services/visibility"
 	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 )
 
+// viewerID - the logged-in user, 0 on public routes
+func viewerID(e echo.Context) int64 {
+	userID, _ := auth.GetUserID(e)
+	return userID
+}
+
+// checkVisible - hidden users look exactly like missing ones
+func (uc *UserController) checkVisible(e echo.Context, userID int64, rule visibility.Rule) error {
+	ok, err := uc.visibility.Allows(viewerID(e), userID, rule)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return userstore.ErrorUserNotFound
+	}
+	return nil
+}
+
+// lookupError - maps errors of user lookups to api responses
+func lookupError(e echo.Context, err error) error {
+	if errors.Is(err, userstore.ErrorUserNotFound) {
+		return response.OtherErrors(e, response.ErrorUserNotFound, "user not found")
+	}
+	return response.ServerError(e, err, "")
+}
+
 func (uc *UserController) GetPublicProfile(e echo.Context) error {
 	var simple *user.Simple
 	var err error
 	if address := e.Param("address"); len(address) > 0 {
 		simple, err = uc.userService.GetSimpleOwnerOfAddress(address, chain.Solana)
+		if err == nil {
+			err = uc.checkVisible(e, simple.ID, visibility.ByAddress)
+		}
 	} else if userID, _ := strconv.ParseInt(e.Param("id"), 10, 64); userID > 0 {
+		//ids aren't searchable, whoever has one found the user through a lookup that was allowed
 		simple, err = uc.userService.GetSimpleByID(userID)
 	} else {
 		return response.BadRequestError(e, "address or id required")
 	}
 	if err != nil {
-		return response.ServerError(e, err, "")
+		return lookupError(e, err)
 	}
 	return response.JSON(e, simple)
 }
@@ -40,13 +76,23 @@ func (uc *UserController) GetManyAddresses(e echo.Context) error {
 		return response.BadRequestError(e, "chain is required")
 	}
 
-	res := make([]user.SimpleByAddress, 0)
-	for _, address := range addresses {
-		simple, err := uc.userService.GetSimpleOwnerOfAddress(address, chain.Chain(c))
-		if err != nil {
-			continue
+	owners, err := uc.userService.GetSimpleOwnersOfAddresses(addresses, chain.Chain(c))
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	ids := make([]int64, 0, len(owners))
+	for _, o := range owners {
+		ids = append(ids, o.ID)
+	}
+	visible, err := uc.visibility.Filter(viewerID(e), ids, visibility.ByAddress)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	res := make([]user.SimpleByAddress, 0, len(owners))
+	for _, o := range owners {
+		if visible[o.ID] {
+			res = append(res, o)
 		}
-		res = append(res, user.SimpleByAddress{Address: address, Simple: *simple})
 	}
 
 	return response.JSON(e, res)
@@ -67,13 +113,25 @@ func (uc *UserController) GetUsername(e echo.Context) error {
 		username = username[1:]
 	}
 	simple, err := uc.userService.GetSimpleByUsername(username)
+	if err == nil {
+		err = uc.checkVisible(e, simple.ID, visibility.ByUsername)
+	}
 	if err != nil {
-		return response.ServerError(e, err, "")
+		return lookupError(e, err)
 	}
-	accounts, err := uc.accountService.GetSimpleByUserID(simple.ID)
+
+	//the accounts tie addresses to the user, they follow the address preference
+	accounts := make([]account.Simple, 0)
+	ok, err := uc.visibility.Allows(viewerID(e), simple.ID, visibility.ByAddress)
 	if err != nil {
 		return response.ServerError(e, err, "")
 	}
+	if ok {
+		accounts, err = uc.accountService.GetSimpleByUserID(simple.ID)
+		if err != nil {
+			return response.ServerError(e, err, "")
+		}
+	}
 
 	res := getUsernameRes{
 		Simple:   *simple,
@@ -81,3 +139,106 @@ func (uc *UserController) GetUsername(e echo.Context) error {
 	}
 	return response.JSON(e, res)
 }
+
+const (
+	searchPageDefault = 20
+	searchPageMax     = 50
+	searchQueryMin    = 2
+	searchQueryMax    = 50
+)
+
+// @Title Search users
+// @Description Fuzzy search on username and name, most relevant first. People I sent to or received from rank higher. Users who turned off username search are left out unless they are me or my contacts, so a page can be shorter than limit, keep paging until one comes back empty.
+// @Param q query string true "username or name, the @ is optional"
+// @Param offset query int false "results to skip, the previous offset plus limit"
+// @Param limit query int false "page size, max 50"
+// @Success 200 {array} user.SearchResult
+// @Route /user/search [get]
+func (uc *UserController) Search(e echo.Context) error {
+	q := strings.TrimPrefix(strings.TrimSpace(e.QueryParam("q")), "@")
+	if len(q) < searchQueryMin || len(q) > searchQueryMax {
+		return response.BadRequestError(e, "q must be between 2 and 50 characters")
+	}
+	offset, _ := strconv.Atoi(e.QueryParam("offset"))
+	limit, _ := strconv.Atoi(e.QueryParam("limit"))
+	if limit <= 0 {
+		limit = searchPageDefault
+	}
+	viewer := auth.MustGetUserID(e)
+	found, err := uc.userService.Search(q, viewer, max(offset, 0), min(limit, searchPageMax))
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	ids := make([]int64, 0, len(found))
+	for _, r := range found {
+		ids = append(ids, r.ID)
+	}
+	visible, err := uc.visibility.Filter(viewer, ids, visibility.ByUsername)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	res := make([]user.SearchResult, 0, len(found))
+	for _, r := range found {
+		if visible[r.ID] {
+			res = append(res, r)
+		}
+	}
+	return response.JSON(e, res)
+}
+
+// @Title Autocomplete username
+// @Description Users whose username starts with the prefix, shortest usernames first. Users who turned off username search are left out unless they are me or my contacts.
+// @Param q query string true "username prefix of at least 2 characters, the @ is optional"
+// @Param limit query int false "max results, up to 25"
+// @Success 200 {array} user.Simple
+// @Route /user/search/autocomplete [get]
+func (uc *UserController) Autocomplete(e echo.Context) error {
+	prefix := strings.TrimPrefix(e.QueryParam("q"), "@")
+	//a single character would match a big part of every username
+	if len(prefix) < searchQueryMin {
+		return response.BadRequestError(e, "q must be at least 2 characters")
+	}
+	//usernames never have anything else, no need to search
+	if !user.IsUsernamePrefix(prefix) {
+		return response.JSON(e, []user.Simple{})
+	}
+	limit, _ := strconv.Atoi(e.QueryParam("limit"))
+	res, err := uc.autocomplete.Search(auth.MustGetUserID(e), prefix, limit)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, res)
+}
+
+// @Title Get attestation
+// @Description Public proof of every account linked to the user, signed with a key from /.well-known/jwks.json, check it with signverifier.VerifyAttestation
+// @Param id path string true "user id"
+// @Success 200 {object} attestation.Document
+// @Route /user/{id}/attestation [get]
+func (uc *UserController) GetAttestation(e echo.Context) error {
+	userID, _ := strconv.ParseInt(e.Param("id"), 10, 64)
+	if userID <= 0 {
+		return response.BadRequestError(e, "id is required")
+	}
+	simple, err := uc.userService.GetSimpleByID(userID)
+	if err == nil {
+		//the document ties every address to the user
+		err = uc.checkVisible(e, simple.ID, visibility.ByAddress)
+	}
+	if err != nil {
+		return lookupError(e, err)
+	}
+	accounts, err := uc.accountService.GetByUserID(simple.ID)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	doc := attestation.New(simple.ID, simple.Username, accounts)
+	canonical, err := doc.Canonical()
+	if err == nil {
+		doc.ServerSignature, err = accesstoken.SignDetached(canonical)
+	}
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	return response.JSON(e, doc)
+}
@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"shogun/internal/model/preferences"
+	"shogun/internal/model/user"
+	"shogun/internal/services/userstore"
+	"shogun/internal/services/visibility"
+	"shogun/internal/services/visibility/visibilitytest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSearchStore struct {
+	userstore.Store
+	results []user.SearchResult
+}
+
+func (f fakeSearchStore) Search(string, int64, int, int) ([]user.SearchResult, error) {
+	return f.results, nil
+}
+
+func TestSearch_AppliesVisibility(t *testing.T) {
+	const (
+		hiddenID  = 1
+		publicID  = 2
+		contactID = 3
+	)
+	off := false
+	store := fakeSearchStore{results: []user.SearchResult{
+		{Simple: user.Simple{ID: hiddenID, Username: "bobby"}},
+		{Simple: user.Simple{ID: publicID, Username: "bobcat"}},
+	}}
+	policy := visibility.New(
+		visibilitytest.Prefs{Of: map[int64]preferences.Preferences{hiddenID: {SearchUsername: &off}}},
+		&visibilitytest.Contacts{Of: map[int64][]int64{contactID: {hiddenID}}},
+	)
+	uc := NewUserController(store, nil, nil, nil, nil, nil, nil, nil, nil, policy)
+
+	search := func(viewerID int64) []int64 {
+		rec := httptest.NewRecorder()
+		e := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/v1/user/search?q=bob", nil), rec)
+		e.Set("access-token-userid", viewerID)
+		assert.NoError(t, uc.Search(e))
+		body := struct {
+			Data []user.SearchResult `json:"data"`
+		}{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		ids := make([]int64, 0)
+		for _, r := range body.Data {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int64{publicID}, search(99))
+	//the same rule as autocomplete and username lookup, self and contacts still find them
+	assert.Equal(t, []int64{hiddenID, publicID}, search(hiddenID))
+	assert.Equal(t, []int64{hiddenID, publicID}, search(contactID))
+}
@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"errors"
+	"shogun/internal/api/middleware/auth"
+	"shogun/internal/api/response"
+	"shogun/internal/model/audit"
+	"shogun/internal/model/session"
+	"shogun/internal/services/auditstore"
+	"shogun/internal/services/sessionrevoke"
+	"shogun/internal/services/sessionstore"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SessionController struct {
+	sessionService sessionstore.Store
+	sessionRevoker sessionrevoke.Checker
+	auditService   auditstore.Recorder
+}
+
+func NewSessionController(ss sessionstore.Store, sr sessionrevoke.Checker, ar auditstore.Recorder) *SessionController {
+	return &SessionController{
+		sessionService: ss,
+		sessionRevoker: sr,
+		auditService:   ar,
+	}
+}
+
+// @Enum sessionResponse
+type sessionResponse struct {
+	session.Session
+	Current bool `json:"current"`
+}
+
+// @Title List Sessions
+// @Description Lists the devices the user is signed in on, most recently seen first.
+// @Success 200 {array} sessionResponse
+// @Route /user/sessions [get]
+func (sc *SessionController) List(e echo.Context) error {
+	userID := auth.MustGetUserID(e)
+	currentID := auth.MustGetSessionID(e)
+	sessions, err := sc.sessionService.GetActiveForUser(userID)
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	res := make([]sessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		res = append(res, sessionResponse{
+			Session: s,
+			Current: s.ID == currentID,
+		})
+	}
+	return response.JSON(e, res)
+}
+
+// @Title Revoke Session
+// @Description Signs out one device, its access and refresh tokens stop working.
+// @Param id path string true "Session id"
+// @Success 200 success
+// @Route /user/sessions/{id} [delete]
+func (sc *SessionController) Revoke(e echo.Context) error {
+	userID := auth.MustGetUserID(e)
+	sessionID, err := strconv.ParseInt(e.Param("id"), 10, 64)
+	if err != nil {
+		return response.BadRequestError(e, "invalid session id")
+	}
+	err = sc.sessionService.RevokeForUser(userID, sessionID)
+	if err != nil {
+		if errors.Is(err, sessionstore.ErrorSessionNotFound) {
+			return response.OtherErrors(e, response.ErrorSessionNotFound, "session not found")
+		}
+		return response.ServerError(e, err, "")
+	}
+	if err = sc.sessionRevoker.Revoke(sessionID); err != nil {
+		return response.ServerError(e, err, "")
+	}
+	recordEvent(sc.auditService, e, userID, audit.ActionSessionRevoke, e.Param("id"), nil)
+	return response.Success(e)
+}
+
+// @Title Revoke All Sessions
+// @Description Signs out every device, pass keep_current=true to stay signed in on this one.
+// @Param keep_current query boolean false "Keep the session making the request"
+// @Success 200 success
+// @Route /user/sessions [delete]
+func (sc *SessionController) RevokeAll(e echo.Context) error {
+	userID := auth.MustGetUserID(e)
+	var ids []int64
+	var err error
+	if e.QueryParam("keep_current") == "true" {
+		ids, err = sc.sessionService.RevokeOthersForUser(userID, auth.MustGetSessionID(e))
+	} else {
+		ids, err = sc.sessionService.RevokeAllForUser(userID)
+	}
+	if err != nil {
+		return response.ServerError(e, err, "")
+	}
+	if len(ids) > 0 {
+		if err = sc.sessionRevoker.Revoke(ids...); err != nil {
+			return response.ServerError(e, err, "")
+		}
+		recordEvent(sc.auditService, e, userID, audit.ActionSessionRevoke, "", audit.Payload{"sessions": ids})
+	}
+	return response.Success(e)
+}
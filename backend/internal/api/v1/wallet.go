@@ -2,22 +2,28 @@ package v1
 
 import (
 	"errors"
+	"shogun/internal/api/middleware/auth"
 	"shogun/internal/api/response"
 	"shogun/internal/model/chain"
+	"shogun/internal/model/transaction"
 	"shogun/internal/services/historyfetch"
+	"shogun/internal/services/screening"
 	"shogun/internal/services/walletstore"
+	"slices"
 	"sort"
 
 	"github.com/labstack/echo/v4"
 )
 
 type WalletController struct {
-	fetcher historyfetch.AllFetcher
+	fetcher  historyfetch.AllFetcher
+	screener screening.Screener
 }
 
-func NewWalletController(fetcher historyfetch.AllFetcher) *WalletController {
+func NewWalletController(fetcher historyfetch.AllFetcher, screener screening.Screener) *WalletController {
 	return &WalletController{
-		fetcher: fetcher,
+		fetcher:  fetcher,
+		screener: screener,
 	}
 }
 
@@ -46,6 +52,7 @@ func (wc *WalletController) FetchAssets(e echo.Context) error {
 	}
 
 	assets.SetUSDValue()
+	assets.SetRisk(wc.screener, query.Address, query.Chain)
 	sort.Slice(assets.Tokens, func(i, j int) bool {
 		return assets.Tokens[i].Total.GreaterThan(assets.Tokens[j].Total)
 	})
@@ -54,10 +61,19 @@ func (wc *WalletController) FetchAssets(e echo.Context) error {
 }
 
 type fetchHistoryQuery struct {
-	Address string      `query:"address" validate:"required"`
-	Chain   chain.Chain `query:"chain" validate:"required"`
+	Address  string      `query:"address" validate:"required"`
+	Chain    chain.Chain `query:"chain" validate:"required"`
+	HideSpam bool        `query:"hide_spam"`
 }
 
+// @Title Wallet history
+// @Description Transactions of the address, newest first. Counterparties on a risk list have risk flags and
+// @Description likely spam (address poisoning, dust, zero value) is marked in spam, hide_spam leaves it out.
+// @Param address query string true "Wallet address"
+// @Param chain query string true "Chain of the address"
+// @Param hide_spam query boolean false "Leave out transactions marked as spam"
+// @Success 200 {array} transaction.Transaction
+// @Route /wallet/history [get]
 func (wc *WalletController) FetchHistory(e echo.Context) error {
 	var query fetchHistoryQuery
 	if err := e.Bind(&query); err != nil {
@@ -66,7 +82,7 @@ func (wc *WalletController) FetchHistory(e echo.Context) error {
 	if err := e.Validate(query); err != nil {
 		return response.BadRequestError(e, err.Error())
 	}
-	history, err := wc.fetcher.Fetch(e.Request().Context(), query.Address, query.Chain)
+	history, err := wc.fetcher.Fetch(e.Request().Context(), auth.MustGetUserID(e), query.Address, query.Chain)
 	if err != nil {
 		switch {
 		case errors.Is(err, historyfetch.ErrorChainNotSupported):
@@ -75,5 +91,10 @@ func (wc *WalletController) FetchHistory(e echo.Context) error {
 			return response.ServerError(e, err, "failed to fetch history")
 		}
 	}
+	if query.HideSpam {
+		history = slices.DeleteFunc(history, func(tx transaction.Transaction) bool {
+			return tx.IsSpam()
+		})
+	}
 	return response.JSON(e, history)
 }
@@ -11,13 +11,22 @@ type Account struct {
 	UserID    int64       `db:"user_id" json:"user_id"`
 	Chain     chain.Chain `db:"chain" json:"chain"`
 	Signature string      `db:"signature" json:"signature"`
+	IsPrimary bool        `db:"is_primary" json:"is_primary"`
 	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+	//ProofSignature is by the address over the proof message naming LinkedBy
+	ProofSignature string `db:"proof_signature" json:"proof_signature"`
+	//LinkedBy is the primary account that linked this one, it made Signature over the link message
+	LinkedBy      string      `db:"linked_by" json:"linked_by"`
+	LinkedByChain chain.Chain `db:"linked_by_chain" json:"linked_by_chain"`
+	//LinkNonce is the link challenge both messages were signed over
+	LinkNonce string `db:"link_nonce" json:"link_nonce"`
 }
 
 type Simple struct {
 	Address   string      `db:"address" json:"address"`
 	Chain     chain.Chain `db:"chain" json:"chain"`
 	Signature string      `db:"signature" json:"signature"`
+	IsPrimary bool        `db:"is_primary" json:"is_primary"`
 }
 
 func New() *Account {
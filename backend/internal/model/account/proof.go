@@ -0,0 +1,31 @@
+package account
+
+import (
+	"fmt"
+	"shogun/internal/model/chain"
+)
+
+//the signed messages are scary on purpose, to stop people from signing them
+//when some random site asks them to
+
+// ProofMessage - signed by the linked address, proves it agrees to be owned by the primary address,
+// the nonce is from the link challenge so a published proof can't link the address again after an unlink
+func ProofMessage(primaryAddress, nonce string) string {
+	return fmt.Sprintf("I agree to give all my money now and in the future to %s\nNonce: %s", primaryAddress, nonce)
+}
+
+// LinkMessage - signed by the primary address, proves the owner wants the address linked,
+// the nonce is from the link challenge
+func LinkMessage(address, nonce string) string {
+	return fmt.Sprintf("I agree to give all my money now and in the future to %s\nNonce: %s", address, nonce)
+}
+
+// UnlinkMessage - signed by the address being removed, the nonce is from the unlink challenge
+func UnlinkMessage(address string, c chain.Chain, nonce string) string {
+	return fmt.Sprintf("Remove %s (%s) from my Shogun account.\nNonce: %s", address, c, nonce)
+}
+
+// SetPrimaryMessage - signed by the address becoming the login key, the nonce is from the set primary challenge
+func SetPrimaryMessage(address string, c chain.Chain, nonce string) string {
+	return fmt.Sprintf("Make %s (%s) the login key of my Shogun account.\nNonce: %s", address, c, nonce)
+}
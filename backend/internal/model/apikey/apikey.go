@@ -0,0 +1,116 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"shogun/internal/utils/hashing"
+	"strings"
+	"time"
+)
+
+// Scope - what a key may call, routes accepting keys name the scope they need
+type Scope string
+
+const (
+	ScopeTokenRead   Scope = "token:read"
+	ScopeUserResolve Scope = "user:resolve"
+)
+
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeTokenRead, ScopeUserResolve:
+		return true
+	}
+	return false
+}
+
+type Scopes []Scope
+
+func (s Scopes) Has(scope Scope) bool {
+	for _, v := range s {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Scopes) IsValid() bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, v := range s {
+		if !v.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Scopes) Scan(src interface{}) error {
+	jsonBytes, ok := src.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(jsonBytes, s)
+}
+
+func (s Scopes) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s)
+}
+
+const (
+	secretPrefix  = "shg_"
+	secretBytes   = 32
+	shownPrefix   = 12
+	NameMaxLength = 64
+)
+
+// Key - an api key of a user, the secret is shown once on creation and only its hash is kept
+type Key struct {
+	ID         int64      `db:"id" json:"id,string"`
+	UserID     int64      `db:"user_id" json:"user_id,string"`
+	Name       string     `db:"name" json:"name"`
+	Prefix     string     `db:"prefix" json:"prefix"`
+	SecretHash string     `db:"secret_hash" json:"-"`
+	Scopes     Scopes     `db:"scopes" json:"scopes"`
+	HourQuota  int64      `db:"hourly_quota" json:"hourly_quota"`
+	CreatedBy  int64      `db:"created_by" json:"created_by,string"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+func New() *Key {
+	return &Key{}
+}
+
+func (k *Key) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Usage - requests made with a key on a day
+type Usage struct {
+	Day      time.Time `db:"day" json:"day"`
+	Requests int64     `db:"requests" json:"requests"`
+}
+
+// GenerateSecret - returns the secret for the client, the prefix shown in listings and the hash we keep
+func GenerateSecret() (string, string, string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	secret := secretPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return secret, secret[:shownPrefix], HashSecret(secret), nil
+}
+
+func HashSecret(secret string) string {
+	return hex.EncodeToString(hashing.Sha256(strings.TrimSpace(secret)))
+}
@@ -0,0 +1,38 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateSecret(t *testing.T) {
+	secret, prefix, hash, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, secretPrefix) || !strings.HasPrefix(secret, prefix) {
+		t.Errorf("unexpected secret %q with prefix %q", secret, prefix)
+	}
+	if hash != HashSecret(secret) || hash != HashSecret(" "+secret+"\n") {
+		t.Error("hash doesn't match the secret")
+	}
+	other, _, _, _ := GenerateSecret()
+	if other == secret {
+		t.Error("secrets repeat")
+	}
+}
+
+func TestScopes(t *testing.T) {
+	s := Scopes{ScopeTokenRead}
+	if !s.IsValid() || !s.Has(ScopeTokenRead) || s.Has(ScopeUserResolve) {
+		t.Error("scope checks are wrong")
+	}
+	if (Scopes{}).IsValid() || (Scopes{"user:write"}).IsValid() {
+		t.Error("invalid scopes accepted")
+	}
+	var scanned Scopes
+	v, _ := s.Value()
+	if err := scanned.Scan(v); err != nil || !scanned.Has(ScopeTokenRead) {
+		t.Errorf("scan round trip failed: %v %v", scanned, err)
+	}
+}
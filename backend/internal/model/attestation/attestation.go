@@ -0,0 +1,139 @@
+package attestation
+
+import (
+	"encoding/json"
+	"shogun/internal/model/account"
+	"shogun/internal/model/chain"
+	"sort"
+	"time"
+)
+
+// Version - bump when the document or the signed messages change, verifiers must reject versions they don't know
+// v2 added ServerSignature and the link nonce, v1 documents didn't tie the accounts to the user id
+// and username and their link messages could be replayed
+const Version = "shogun-attestation-v2"
+
+// Document - public proof of every account linked to a user, see signverifier.VerifyAttestation
+// the wallet signatures only tie accounts to each other and anyone can check them without trusting us,
+// the user id, the username and accounts without statements, like the only key of a user who never
+// linked another one, are vouched for by ServerSignature alone
+type Document struct {
+	Version  string    `json:"version"`
+	UserID   int64     `json:"user_id,string"`
+	Username string    `json:"username"`
+	Accounts []Entry   `json:"accounts"`
+	IssuedAt time.Time `json:"issued_at"`
+	//ServerSignature is a detached JWS over Canonical made with a key from /.well-known/jwks.json
+	ServerSignature string `json:"server_signature,omitempty"`
+}
+
+// Entry - one linked account and the two statements that link it
+// Proof and Link are nil for the sign up key and accounts linked before proofs were bound to a link challenge
+type Entry struct {
+	Address   string      `json:"address"`
+	Chain     chain.Chain `json:"chain"`
+	IsPrimary bool        `json:"is_primary"`
+	LinkedAt  time.Time   `json:"linked_at"`
+	//LinkedBy is the primary account at the time of linking
+	LinkedBy *Signer `json:"linked_by"`
+	//LinkNonce is the link challenge both statements name
+	LinkNonce string `json:"link_nonce,omitempty"`
+	//Proof is signed by Address, it agrees to be owned by LinkedBy
+	Proof *Statement `json:"proof"`
+	//Link is signed by LinkedBy, it wants Address linked
+	Link *Statement `json:"link"`
+}
+
+type Signer struct {
+	Address string      `json:"address"`
+	Chain   chain.Chain `json:"chain"`
+}
+
+// Statement - the exact message that was signed, who signed it and the signature
+type Statement struct {
+	Signer    Signer `json:"signer"`
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+// New - builds the document from the accounts of the user
+// accounts are sorted by chain then address so the same accounts always give the same document
+func New(userID int64, username string, accounts []account.Account) *Document {
+	entries := make([]Entry, 0, len(accounts))
+	for _, acc := range accounts {
+		entries = append(entries, newEntry(acc))
+	}
+	//the account that linked this one was unlinked since, its statements can't be checked anymore
+	for i := range entries {
+		if entries[i].LinkedBy != nil && !hasAccount(accounts, *entries[i].LinkedBy) {
+			entries[i].LinkedBy, entries[i].LinkNonce, entries[i].Proof, entries[i].Link = nil, "", nil, nil
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Chain != entries[j].Chain {
+			return entries[i].Chain < entries[j].Chain
+		}
+		return entries[i].Address < entries[j].Address
+	})
+	return &Document{
+		Version:  Version,
+		UserID:   userID,
+		Username: username,
+		Accounts: entries,
+		IssuedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func newEntry(acc account.Account) Entry {
+	entry := Entry{
+		Address:   acc.Address,
+		Chain:     acc.Chain,
+		IsPrimary: acc.IsPrimary,
+		LinkedAt:  acc.CreatedAt.UTC().Truncate(time.Second),
+	}
+	//without a nonce the messages could be replayed, they prove nothing worth publishing
+	if acc.LinkedBy == "" || acc.ProofSignature == "" || acc.LinkNonce == "" {
+		return entry
+	}
+	linkedBy := Signer{Address: acc.LinkedBy, Chain: acc.LinkedByChain}
+	entry.LinkedBy = &linkedBy
+	entry.LinkNonce = acc.LinkNonce
+	entry.Proof = &Statement{
+		Signer:    Signer{Address: acc.Address, Chain: acc.Chain},
+		Message:   account.ProofMessage(acc.LinkedBy, acc.LinkNonce),
+		Signature: acc.ProofSignature,
+	}
+	entry.Link = &Statement{
+		Signer:    linkedBy,
+		Message:   account.LinkMessage(acc.Address, acc.LinkNonce),
+		Signature: acc.Signature,
+	}
+	return entry
+}
+
+func hasAccount(accounts []account.Account, s Signer) bool {
+	for _, acc := range accounts {
+		if acc.Address == s.Address && acc.Chain == s.Chain {
+			return true
+		}
+	}
+	return false
+}
+
+// Canonical - the serialized document without ServerSignature, what the server signs,
+// fields are always in the same order
+func (d *Document) Canonical() ([]byte, error) {
+	unsigned := *d
+	unsigned.ServerSignature = ""
+	return json.Marshal(unsigned)
+}
+
+// Find - the entry of the address, nil if the document doesn't list it
+func (d *Document) Find(address string, c chain.Chain) *Entry {
+	for i := range d.Accounts {
+		if d.Accounts[i].Address == address && d.Accounts[i].Chain == c {
+			return &d.Accounts[i]
+		}
+	}
+	return nil
+}
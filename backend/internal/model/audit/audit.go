@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"shogun/internal/utils/text"
+	"time"
+)
+
+const (
+	TargetMaxLength    = 128
+	UserAgentMaxLength = 256
+)
+
+type Action string
+
+const (
+	ActionSignUp            Action = "sign_up"
+	ActionLogin             Action = "login"
+	ActionLogout            Action = "logout"
+	ActionAccountLink       Action = "account_link"
+	ActionAccountUnlink     Action = "account_unlink"
+	ActionPrimaryChange     Action = "primary_change"
+	ActionSessionRevoke     Action = "session_revoke"
+	ActionProfileUpdate     Action = "profile_update"
+	ActionUsernameChange    Action = "username_change"
+	ActionThumbnailUpload   Action = "thumbnail_upload"
+	ActionPreferencesUpdate Action = "preferences_update"
+	ActionDeleteRequest     Action = "delete_request"
+	ActionUserSuspend       Action = "user_suspend"
+	ActionUserUnsuspend     Action = "user_unsuspend"
+	ActionUsernameForced    Action = "username_forced"
+	ActionUsernameUnlock    Action = "username_unlock"
+	ActionRoleChange        Action = "role_change"
+	ActionTokenOverride     Action = "token_override"
+	ActionApiKeyCreate      Action = "api_key_create"
+	ActionApiKeyRevoke      Action = "api_key_revoke"
+	ActionDevicePair        Action = "device_pair"
+	ActionUsernameReserve   Action = "username_reserve"
+	ActionUsernameUnreserve Action = "username_unreserve"
+	//no user exists yet, user_id is 0 and the target is the address
+	ActionSignupBlocked Action = "signup_blocked"
+)
+
+// Payload - action specific details, keep it small and never put secrets in it
+type Payload map[string]any
+
+func (p *Payload) Scan(src interface{}) error {
+	jsonBytes, ok := src.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(jsonBytes, p)
+}
+
+func (p Payload) Value() (driver.Value, error) {
+	if p == nil {
+		return []byte("{}"), nil
+	}
+	jsonBytes, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return jsonBytes, nil
+}
+
+// Event - something that happened to a user's account, UserID is whose history it belongs to
+// and ActorID who did it, they differ when staff acts on a user
+type Event struct {
+	ID        int64     `db:"id" json:"id,string"`
+	UserID    int64     `db:"user_id" json:"user_id,string"`
+	ActorID   int64     `db:"actor_id" json:"actor_id,string"`
+	Action    Action    `db:"action" json:"action"`
+	Target    string    `db:"target" json:"target"`
+	IP        string    `db:"ip" json:"ip"`
+	UserAgent string    `db:"user_agent" json:"user_agent"`
+	Payload   Payload   `db:"payload" json:"payload"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+func New() *Event {
+	return &Event{}
+}
+
+// Truncate - keeps client supplied values within the column sizes, a value postgres refuses
+// would lose the event
+func (ev *Event) Truncate() {
+	ev.Target = text.Truncate(ev.Target, TargetMaxLength)
+	ev.UserAgent = text.Truncate(ev.UserAgent, UserAgentMaxLength)
+}
+
+// Filter - staff queries, zero values are not filtered on, Before is an event id cursor
+type Filter struct {
+	UserID  int64
+	ActorID int64
+	Action  Action
+	Before  int64
+	Limit   int
+}
+
+// HourCount - sign ups and blocked sign ups of an hour
+type HourCount struct {
+	Hour    time.Time `db:"hour" json:"hour"`
+	SignUps int64     `db:"sign_ups" json:"sign_ups"`
+	Blocked int64     `db:"blocked" json:"blocked"`
+	IPs     int64     `db:"ips" json:"ips"`
+}
+
+// SourceCount - sign ups and blocked sign ups from an ip or subnet
+type SourceCount struct {
+	Source  string `db:"source" json:"source"`
+	SignUps int64  `db:"sign_ups" json:"sign_ups"`
+	Blocked int64  `db:"blocked" json:"blocked"`
+}
@@ -0,0 +1,24 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvent_Truncate(t *testing.T) {
+	ev := &Event{
+		Target:    strings.Repeat("t", 127) + "ü",
+		UserAgent: strings.Repeat("a", 255) + "🦊🦊",
+	}
+	ev.Truncate()
+	assert.Equal(t, strings.Repeat("t", 127)+"ü", ev.Target)
+	assert.Equal(t, strings.Repeat("a", 255)+"🦊", ev.UserAgent)
+	assert.True(t, utf8.ValidString(ev.UserAgent))
+
+	ev = &Event{Target: strings.Repeat("界", 200)}
+	ev.Truncate()
+	assert.Equal(t, TargetMaxLength, utf8.RuneCountInString(ev.Target))
+}
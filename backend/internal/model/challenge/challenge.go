@@ -0,0 +1,107 @@
+package challenge
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"shogun/internal/model/chain"
+	"strings"
+	"time"
+)
+
+type Action string
+
+const (
+	ActionLogin      Action = "login"
+	ActionExists     Action = "exists"
+	ActionLink       Action = "link"
+	ActionUnlink     Action = "unlink"
+	ActionSetPrimary Action = "set_primary"
+	ActionRequest    Action = "request"
+)
+
+var statements = map[Action]string{
+	ActionLogin:      "Sign in to Shogun.",
+	ActionExists:     "Check if this account exists on Shogun.",
+	ActionLink:       "Link accounts to your Shogun account.",
+	ActionUnlink:     "Remove an account from your Shogun account.",
+	ActionSetPrimary: "Change the login key of your Shogun account.",
+	ActionRequest:    "Approve a request to your Shogun account.",
+}
+
+func (a Action) IsValid() bool {
+	_, ok := statements[a]
+	return ok
+}
+
+// Challenge - a single use nonce the wallet signs to prove it holds the key,
+// modeled on Sign-In-With-Solana so wallets show users a readable message
+type Challenge struct {
+	Nonce     string      `json:"nonce"`
+	Domain    string      `json:"domain"`
+	URI       string      `json:"uri"`
+	Address   string      `json:"address"`
+	Chain     chain.Chain `json:"chain"`
+	Action    Action      `json:"action"`
+	IssuedAt  time.Time   `json:"issued_at"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+func New(domain, uri, address string, c chain.Chain, action Action, ttl time.Duration) *Challenge {
+	now := time.Now().UTC().Truncate(time.Second)
+	return &Challenge{
+		Nonce:     newNonce(),
+		Domain:    domain,
+		URI:       uri,
+		Address:   address,
+		Chain:     c,
+		Action:    action,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func (c *Challenge) IsExpired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+// Message - the exact text the wallet signs
+func (c *Challenge) Message() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s wants you to sign in with your %s account:\n", c.Domain, chainTitle(c.Chain)))
+	b.WriteString(c.Address)
+	b.WriteString("\n\n")
+	b.WriteString(statements[c.Action])
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("URI: %s\n", c.URI))
+	b.WriteString("Version: 1\n")
+	b.WriteString(fmt.Sprintf("Chain ID: %s\n", c.Chain))
+	b.WriteString(fmt.Sprintf("Nonce: %s\n", c.Nonce))
+	b.WriteString(fmt.Sprintf("Issued At: %s\n", c.IssuedAt.Format(time.RFC3339)))
+	b.WriteString(fmt.Sprintf("Expiration Time: %s", c.ExpiresAt.Format(time.RFC3339)))
+	return b.String()
+}
+
+// MessageWithPayload - the message for flows that also carry a request body,
+// the body is bound to the challenge through its sha256 hash
+func (c *Challenge) MessageWithPayload(payload []byte) string {
+	hash := sha256.Sum256(payload)
+	return fmt.Sprintf("%s\nRequest Hash: %s", c.Message(), hex.EncodeToString(hash[:]))
+}
+
+func chainTitle(c chain.Chain) string {
+	s := string(c)
+	if len(s) == 0 {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,32 @@
+package contact
+
+import "time"
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusAccepted Status = "accepted"
+)
+
+// Contact - a request from UserID to ContactID, or one side of two accepted contacts
+type Contact struct {
+	ID        int64     `db:"id" json:"id,string"`
+	UserID    int64     `db:"user_id" json:"user_id,string"`
+	ContactID int64     `db:"contact_id" json:"contact_id,string"`
+	Status    Status    `db:"status" json:"status"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func New() *Contact {
+	return &Contact{}
+}
+
+// Other - the user on the other side from userID
+func (c *Contact) Other(userID int64) int64 {
+	if c.UserID == userID {
+		return c.ContactID
+	}
+	return c.UserID
+}
@@ -0,0 +1,13 @@
+package contact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContact_Other(t *testing.T) {
+	c := &Contact{UserID: 1, ContactID: 2}
+	assert.Equal(t, int64(2), c.Other(1))
+	assert.Equal(t, int64(1), c.Other(2))
+}
@@ -0,0 +1,80 @@
+package pairing
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"shogun/internal/model/session"
+	"shogun/internal/utils/hashing"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusRejected Status = "rejected"
+	StatusClaimed  Status = "claimed"
+)
+
+// codeEncoding - upper case letters and digits so the code still works typed from the screen
+var codeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Pairing - a desktop waiting to be signed in by a logged-in phone, the code goes in the QR
+// and the secret stays on the desktop so only it can pick up the session
+type Pairing struct {
+	Code       string         `json:"code"`
+	SecretHash string         `json:"secret_hash"`
+	Status     Status         `json:"status"`
+	Device     session.Device `json:"device"`
+	UserID     int64          `json:"user_id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+}
+
+// New - returns the pairing and the secret for the desktop, only its hash is kept
+func New(device session.Device, ttl time.Duration) (*Pairing, string) {
+	secret := base64.RawURLEncoding.EncodeToString(randomBytes(32))
+	now := time.Now().UTC().Truncate(time.Second)
+	return &Pairing{
+		Code:       codeEncoding.EncodeToString(randomBytes(10)),
+		SecretHash: hashSecret(secret),
+		Status:     StatusPending,
+		Device:     device,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}, secret
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func hashSecret(secret string) string {
+	return hex.EncodeToString(hashing.Sha256(secret))
+}
+
+func (p *Pairing) IsSecretValid(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(p.SecretHash), []byte(hashSecret(secret))) == 1
+}
+
+func (p *Pairing) IsExpired() bool {
+	return time.Now().After(p.ExpiresAt)
+}
+
+// IsResolved - the phone answered, waiting desktops can stop
+func (p *Pairing) IsResolved() bool {
+	return p.Status != StatusPending
+}
+
+// URI - what the QR encodes, the app opens it to approve
+func (p *Pairing) URI() string {
+	return "shogun://pair/" + p.Code
+}
@@ -0,0 +1,34 @@
+package pairing
+
+import (
+	"shogun/internal/model/session"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	p, secret := New(session.Device{DeviceName: "MacBook"}, time.Minute)
+	if p.Status != StatusPending || p.IsResolved() || p.IsExpired() {
+		t.Fatalf("new pairing should be pending, got %+v", p)
+	}
+	if len(p.Code) != 16 {
+		t.Errorf("code should be 16 characters, got %q", p.Code)
+	}
+	if !p.IsSecretValid(secret) || p.IsSecretValid(secret+"x") || p.IsSecretValid("") {
+		t.Error("secret check is wrong")
+	}
+	if p.URI() != "shogun://pair/"+p.Code {
+		t.Errorf("unexpected uri %s", p.URI())
+	}
+	other, otherSecret := New(session.Device{}, time.Minute)
+	if other.Code == p.Code || otherSecret == secret {
+		t.Error("codes or secrets repeat")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	p, _ := New(session.Device{}, -time.Second)
+	if !p.IsExpired() {
+		t.Error("pairing should be expired")
+	}
+}
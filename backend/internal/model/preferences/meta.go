@@ -41,3 +41,13 @@ func DefaultPreferences() Preferences {
 		LastSeen:        &d,
 	}
 }
+
+// UsernameSearchable - whether others can find the user by username, unset means the default which is on
+func (m Preferences) UsernameSearchable() bool {
+	return m.SearchUsername == nil || *m.SearchUsername
+}
+
+// AddressSearchable - whether others can find the user by one of their addresses, unset means on
+func (m Preferences) AddressSearchable() bool {
+	return m.SearchAddress == nil || *m.SearchAddress
+}
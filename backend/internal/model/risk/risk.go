@@ -0,0 +1,72 @@
+package risk
+
+import (
+	"shogun/internal/model/chain"
+)
+
+type Category string
+
+const (
+	CategorySanctioned Category = "sanctioned"
+	CategoryScam       Category = "scam"
+	CategoryPhishing   Category = "phishing"
+	CategoryDrainer    Category = "drainer"
+	CategoryMixer      Category = "mixer"
+)
+
+func (c Category) IsValid() bool {
+	switch c {
+	case CategorySanctioned, CategoryScam, CategoryPhishing, CategoryDrainer, CategoryMixer:
+		return true
+	}
+	return false
+}
+
+type Level string
+
+const (
+	LevelNone Level = "none"
+	// LevelWarn - the app asks the user to confirm before sending
+	LevelWarn Level = "warn"
+	// LevelBlock - the app doesn't let the user send at all
+	LevelBlock Level = "block"
+)
+
+// Flag - why an address is risky and which list said so
+type Flag struct {
+	Category Category `json:"category"`
+	Source   string   `json:"source"`
+	Label    string   `json:"label,omitempty"`
+}
+
+// Result - screening of one address, flags is empty for clean addresses
+type Result struct {
+	Address string      `json:"address"`
+	Chain   chain.Chain `json:"chain"`
+	Level   Level       `json:"level"`
+	Flags   []Flag      `json:"flags"`
+}
+
+func NewResult(address string, c chain.Chain, flags []Flag) Result {
+	if flags == nil {
+		flags = make([]Flag, 0)
+	}
+	return Result{
+		Address: address,
+		Chain:   c,
+		Level:   LevelOf(flags),
+		Flags:   flags,
+	}
+}
+
+// LevelOf - sanctioned addresses are blocked, anything else flagged is a warning
+func LevelOf(flags []Flag) Level {
+	level := LevelNone
+	for _, f := range flags {
+		if f.Category == CategorySanctioned {
+			return LevelBlock
+		}
+		level = LevelWarn
+	}
+	return level
+}
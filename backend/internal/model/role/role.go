@@ -0,0 +1,58 @@
+package role
+
+// Role - staff role of a user, regular users have none
+type Role string
+
+const (
+	None    Role = ""
+	Admin   Role = "admin"
+	Support Role = "support"
+)
+
+type Permission string
+
+const (
+	PermissionUsersRead        Permission = "users:read"
+	PermissionUsersSuspend     Permission = "users:suspend"
+	PermissionUsersRename      Permission = "users:rename"
+	PermissionRolesManage      Permission = "roles:manage"
+	PermissionTokensEdit       Permission = "tokens:edit"
+	PermissionAuditRead        Permission = "audit:read"
+	PermissionApiKeysEdit      Permission = "api_keys:edit"
+	PermissionUsernamesReserve Permission = "usernames:reserve"
+)
+
+var permissions = map[Role][]Permission{
+	Admin: {
+		PermissionUsersRead,
+		PermissionUsersSuspend,
+		PermissionUsersRename,
+		PermissionRolesManage,
+		PermissionTokensEdit,
+		PermissionAuditRead,
+		PermissionApiKeysEdit,
+		PermissionUsernamesReserve,
+	},
+	Support: {
+		PermissionUsersRead,
+		PermissionUsersSuspend,
+		PermissionAuditRead,
+	},
+}
+
+func (r Role) IsValid() bool {
+	if r == None {
+		return true
+	}
+	_, ok := permissions[r]
+	return ok
+}
+
+func (r Role) Can(p Permission) bool {
+	for _, rp := range permissions[r] {
+		if rp == p {
+			return true
+		}
+	}
+	return false
+}
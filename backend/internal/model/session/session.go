@@ -0,0 +1,68 @@
+package session
+
+import (
+	"shogun/internal/utils/text"
+	"time"
+)
+
+type Platform string
+
+const (
+	PlatformUnknown Platform = ""
+	PlatformIOS     Platform = "ios"
+	PlatformAndroid Platform = "android"
+	PlatformDesktop Platform = "desktop"
+	PlatformWeb     Platform = "web"
+)
+
+func (p Platform) IsValid() bool {
+	switch p {
+	case PlatformUnknown, PlatformIOS, PlatformAndroid, PlatformDesktop, PlatformWeb:
+		return true
+	}
+	return false
+}
+
+const (
+	DeviceNameMaxLength = 64
+	UserAgentMaxLength  = 256
+)
+
+// Device - where the session was started from, as reported by the client and the request
+type Device struct {
+	DeviceName string   `db:"device_name" json:"device_name"`
+	Platform   Platform `db:"platform" json:"platform"`
+	IP         string   `db:"ip" json:"ip"`
+	UserAgent  string   `db:"user_agent" json:"user_agent"`
+}
+
+// Truncate - keeps client supplied values within the column sizes
+func (d *Device) Truncate() {
+	d.DeviceName = text.Truncate(d.DeviceName, DeviceNameMaxLength)
+	d.UserAgent = text.Truncate(d.UserAgent, UserAgentMaxLength)
+	if !d.Platform.IsValid() {
+		d.Platform = PlatformUnknown
+	}
+}
+
+// Session - a logged-in device, the refresh token is never stored, only its hash
+type Session struct {
+	ID                int64      `db:"id" json:"id,string"`
+	UserID            int64      `db:"user_id" json:"-"`
+	RefreshTokenHash  string     `db:"refresh_token_hash" json:"-"`
+	PreviousTokenHash string     `db:"previous_token_hash" json:"-"`
+	ExpiresAt         time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt         *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	LastSeenAt        time.Time  `db:"last_seen_at" json:"last_seen_at"`
+	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time  `db:"updated_at" json:"updated_at"`
+	Device
+}
+
+func New() *Session {
+	return &Session{}
+}
+
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && s.ExpiresAt.After(time.Now())
+}
@@ -0,0 +1,27 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevice_Truncate(t *testing.T) {
+	d := &Device{
+		//63 characters then an emoji that crosses the old byte limit
+		DeviceName: strings.Repeat("a", 63) + "📱📱",
+		UserAgent:  strings.Repeat("東", 300),
+		Platform:   "toaster",
+	}
+	d.Truncate()
+	assert.Equal(t, strings.Repeat("a", 63)+"📱", d.DeviceName)
+	assert.True(t, utf8.ValidString(d.DeviceName))
+	assert.Equal(t, UserAgentMaxLength, utf8.RuneCountInString(d.UserAgent))
+	assert.Equal(t, PlatformUnknown, d.Platform)
+
+	short := &Device{DeviceName: "Baraka's 📱"}
+	short.Truncate()
+	assert.Equal(t, "Baraka's 📱", short.DeviceName)
+}
@@ -27,6 +27,14 @@ type Token struct {
 	CreatedAt time.Time   `db:"created_at" json:"-"`
 }
 
+// Override - fields an operator corrects by hand, nil fields are left as they are
+type Override struct {
+	Name     *string `json:"name"`
+	Symbol   *string `json:"symbol"`
+	Decimals *int    `json:"decimals"`
+	Logo     *string `json:"logo"`
+}
+
 // Meta - Add more token specific instructions in here
 type Meta struct {
 	SuiObjectID string `json:"sui_object_id,omitempty"`
@@ -1,6 +1,7 @@
 package transaction
 
 import (
+	"shogun/internal/model/risk"
 	"shogun/internal/model/token"
 	"shogun/internal/model/user"
 
@@ -15,6 +16,16 @@ const (
 	TypeUnknown  Type = "unknown"
 )
 
+// SpamReason - why a transaction is likely spam, wallets hide these or warn about them
+type SpamReason string
+
+const (
+	// SpamPoisoning - the counterparty looks like one the user really sent to, made to get copied by mistake
+	SpamPoisoning SpamReason = "address_poisoning"
+	SpamDust      SpamReason = "dust"
+	SpamZeroValue SpamReason = "zero_value"
+)
+
 type Transaction struct {
 	Type        Type         `json:"type"`
 	Signature   string       `json:"signature"`
@@ -25,6 +36,20 @@ type Transaction struct {
 	Changes     []Transfer   `json:"changes"`
 	Failed      bool         `json:"failed"`
 	User        *user.Simple `json:"user"`
+	Risk        []risk.Flag  `json:"risk,omitempty"` //flags of the counterparty
+	Spam        []SpamReason `json:"spam,omitempty"`
+}
+
+func (t *Transaction) IsSpam() bool {
+	return len(t.Spam) > 0
+}
+
+// Counterparty - the other side of the transaction for the wallet at address
+func (t *Transaction) Counterparty(address string) string {
+	if t.FromAddress == address {
+		return t.ToAddress
+	}
+	return t.FromAddress
 }
 
 type Fee struct {
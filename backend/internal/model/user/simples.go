@@ -17,7 +17,21 @@ type SimpleByAddress struct {
 	Address string `db:"address" json:"address"`
 }
 
+// SearchResult - a user found by search, Interacted is set when the searcher sent to or received from them
+type SearchResult struct {
+	Simple
+	Interacted bool    `db:"interacted" json:"interacted"`
+	Score      float64 `db:"score" json:"-"`
+}
+
 type Address struct {
 	Address string      `db:"address" json:"address"`
 	Chain   chain.Chain `db:"chain" json:"chain"`
 }
+
+// Deleted - what caches need to forget about an erased user
+type Deleted struct {
+	ID        int64     `json:"id,string"`
+	Username  string    `json:"username"`
+	Addresses []Address `json:"addresses"`
+}
@@ -16,6 +16,10 @@ type User struct {
 	Meta      Meta        `db:"meta" json:"meta"`
 	CreatedAt time.Time   `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time   `db:"updated_at" json:"updated_at"`
+	//DeleteAfter is set while the account waits to be erased, logging in cancels it
+	DeleteAfter     *time.Time `db:"delete_after" json:"delete_after,omitempty"`
+	SuspendedAt     *time.Time `db:"suspended_at" json:"suspended_at,omitempty"`
+	SuspendedReason string     `db:"suspended_reason" json:"suspended_reason,omitempty"`
 }
 
 type Updatable struct {
@@ -32,6 +36,11 @@ func IsUsernameValid(username string) bool {
 	return len(username) >= config.Cfg.UsernameMinLengthNormal && len(username) <= config.Cfg.UsernameMaxLength && checkIsAlphaNumericWithUnderscore(username)
 }
 
+// IsUsernamePrefix - whether some valid username could start with prefix
+func IsUsernamePrefix(prefix string) bool {
+	return len(prefix) <= config.Cfg.UsernameMaxLength && checkIsAlphaNumericWithUnderscore(prefix)
+}
+
 func IsNameValid(name string) bool {
 	return len(name) >= 1 && len(name) <= 50
 }
@@ -0,0 +1,41 @@
+package user
+
+import (
+	"strings"
+	"time"
+)
+
+// ReservedMatch - how a reserved word blocks usernames
+type ReservedMatch string
+
+const (
+	ReservedExact    ReservedMatch = "exact"
+	ReservedContains ReservedMatch = "contains"
+)
+
+func (m ReservedMatch) IsValid() bool {
+	return m == ReservedExact || m == ReservedContains
+}
+
+// ReservedWord - nobody can take a username the word blocks, admins keep the list
+type ReservedWord struct {
+	Word      string        `db:"word" json:"word"`
+	Match     ReservedMatch `db:"match_type" json:"match"`
+	CreatedBy int64         `db:"created_by" json:"created_by,string"`
+	CreatedAt time.Time     `db:"created_at" json:"created_at"`
+}
+
+// Blocks - whether the word keeps username from being used
+func (w ReservedWord) Blocks(username string) bool {
+	if w.Match == ReservedContains {
+		return strings.Contains(strings.ToLower(username), strings.ToLower(w.Word))
+	}
+	return strings.EqualFold(username, w.Word)
+}
+
+// PastUsername - a username the user gave up, nobody else can take it until ReclaimableUntil
+type PastUsername struct {
+	Username         string    `db:"username" json:"username"`
+	ReleasedAt       time.Time `db:"released_at" json:"released_at"`
+	ReclaimableUntil time.Time `db:"-" json:"reclaimable_until"`
+}
@@ -0,0 +1,25 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservedWord_Blocks(t *testing.T) {
+	exact := ReservedWord{Word: "Admin", Match: ReservedExact}
+	assert.True(t, exact.Blocks("admin"))
+	assert.True(t, exact.Blocks("ADMIN"))
+	assert.False(t, exact.Blocks("admin_1"))
+	assert.False(t, exact.Blocks("badminton"))
+
+	contains := ReservedWord{Word: "shogun", Match: ReservedContains}
+	assert.True(t, contains.Blocks("shogun"))
+	assert.True(t, contains.Blocks("Shogun_Support"))
+	assert.True(t, contains.Blocks("real_SHOGUN"))
+	assert.False(t, contains.Blocks("shogu_n"))
+
+	assert.True(t, ReservedExact.IsValid())
+	assert.True(t, ReservedContains.IsValid())
+	assert.False(t, ReservedMatch("prefix").IsValid())
+}
@@ -1,9 +1,14 @@
 package accesstoken
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"shogun/config"
+	"shogun/internal/model/role"
+	"shogun/internal/utils/hashing"
 	"strconv"
 	"strings"
 	"time"
@@ -13,55 +18,105 @@ import (
 )
 
 var ErrorInvalidUser = errors.New("invalid user")
+var ErrorInvalidSession = errors.New("invalid session")
 var ErrorTokenExpired = errors.New("token expired")
 var ErrorTokenUsedBeforeTime = errors.New("token used before time")
 
-const (
-	tokenDuration = 30000 * time.Minute
-)
+const refreshTokenBytes = 32
+
+var keyring *Keyring
+
+// Init - loads the signing keys from config, in dev mode without keys an
+// ephemeral key is generated so local runs don't need any setup
+func Init() {
+	if config.Cfg.AccessTokenKeysDir == "" && !config.Cfg.IsRelease() {
+		log.Warn().Msg("access_token_keys_dir not set, using an ephemeral signing key")
+		keyring = NewEphemeralKeyring()
+		return
+	}
+	k, err := LoadKeyring(config.Cfg.AccessTokenKeysDir, config.Cfg.AccessTokenActiveKey)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load access token keys")
+	}
+	keyring = k
+}
+
+// SetKeyring - replaces the keys in use, for tests and tools
+func SetKeyring(k *Keyring) {
+	keyring = k
+}
 
-func GenerateTokenForUser(id int64) (string, int64) {
-	signingKey := []byte(config.Cfg.AccessTokenSecret)
+// Duration - how long an access token lives, keep it short, refresh tokens
+// are used to get new ones
+func Duration() time.Duration {
+	return time.Duration(config.Cfg.AccessTokenMinutes) * time.Minute
+}
+
+// RefreshDuration - how long a refresh token lives, every refresh pushes it forward
+func RefreshDuration() time.Duration {
+	return time.Duration(config.Cfg.RefreshTokenDays) * 24 * time.Hour
+}
 
+// GenerateTokenForUser - r is the staff role of the user, role.None for everyone else
+func GenerateTokenForUser(id, sessionID int64, r role.Role) (string, int64) {
 	nowTime := time.Now().Unix()
-	expireAt := time.Now().Add(tokenDuration).Unix()
+	expireAt := time.Now().Add(Duration()).Unix()
 	claims := jwt.MapClaims{
 		"aud": fmt.Sprintf("%d", id),
+		"sid": fmt.Sprintf("%d", sessionID),
 		"iat": nowTime,
 		"nbf": nowTime,
 		"exp": expireAt,
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString(signingKey)
+	if r != role.None {
+		claims["role"] = string(r)
+	}
+	tokenStr, err := keyring.sign(claims)
 	if err != nil {
 		log.Fatal().Err(err).Send()
 	}
 	return tokenStr, expireAt
 }
 
-func Validate(accessToken string) (int64, error) {
+// GenerateRefreshToken - returns an opaque refresh token for the client and the hash we keep
+func GenerateRefreshToken() (string, string) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal().Err(err).Msg("failed to generate refresh token")
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashRefreshToken(token)
+}
+
+func HashRefreshToken(token string) string {
+	return hex.EncodeToString(hashing.Sha256(token))
+}
+
+func Validate(accessToken string) (*Claims, error) {
 	parser := new(jwt.Parser)
 	parser.UseJSONNumber = true
-	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(config.Cfg.AccessTokenSecret), nil
-	})
+	token, err := parser.Parse(accessToken, keyring.keyFunc)
 	if err != nil {
 		if strings.Contains(err.Error(), "expired") {
-			return 0, ErrorTokenExpired
+			return nil, ErrorTokenExpired
 		}
-		return 0, err
+		return nil, err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
-		userID, err := strconv.ParseInt(claims["aud"].(string), 10, 64)
-		if err != nil {
-			return 0, err
-		}
-		return userID, nil
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrorInvalidUser
+	}
+	aud, _ := mapClaims["aud"].(string)
+	userID, err := strconv.ParseInt(aud, 10, 64)
+	if err != nil {
+		return nil, ErrorInvalidUser
+	}
+	sid, _ := mapClaims["sid"].(string)
+	sessionID, err := strconv.ParseInt(sid, 10, 64)
+	if err != nil {
+		return nil, ErrorInvalidSession
 	}
-	return 0, err
+	r, _ := mapClaims["role"].(string)
+	return &Claims{UserID: userID, SessionID: sessionID, Role: role.Role(r)}, nil
 }
@@ -1,13 +1,16 @@
 package accesstoken
 
 import (
+	"shogun/internal/model/role"
 	"time"
 )
 
 type Claims struct {
-	UserID   int64 `json:"aud,string,omitempty"`
-	IssuedAt int64 `json:"iat,string,omitempty"`
-	ExpireAt int64 `json:"exp,string,omitempty"`
+	UserID    int64     `json:"aud,string,omitempty"`
+	SessionID int64     `json:"sid,string,omitempty"`
+	Role      role.Role `json:"role,omitempty"`
+	IssuedAt  int64     `json:"iat,string,omitempty"`
+	ExpireAt  int64     `json:"exp,string,omitempty"`
 }
 
 func (c Claims) verifyAudience() bool {
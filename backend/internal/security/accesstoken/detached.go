@@ -0,0 +1,107 @@
+package accesstoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var ErrorInvalidSignature = errors.New("invalid detached signature")
+
+// DetachedType - typ of detached signatures, access tokens with it are refused by Validate
+// so a signed document can't be sent as a token even though both use the same keys
+const DetachedType = "shogun-attestation+jws"
+
+type detachedHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// SignDetached - a compact JWS over payload made with the active key, the payload part is left
+// empty (RFC 7515 appendix F) so the document it signs is sent only once, anyone can check it
+// with the keys at /.well-known/jwks.json
+func SignDetached(payload []byte) (string, error) {
+	return keyring.signDetached(payload)
+}
+
+func (k *Keyring) signDetached(payload []byte) (string, error) {
+	header, err := json.Marshal(detachedHeader{Alg: k.active.method.Alg(), Kid: k.active.kid, Typ: DetachedType})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(header)
+	sig, err := k.active.method.Sign(encoded+"."+base64.RawURLEncoding.EncodeToString(payload), k.active.private)
+	if err != nil {
+		return "", err
+	}
+	return encoded + ".." + sig, nil
+}
+
+// VerifyDetached - checks a SignDetached signature over payload with one of the published keys
+func VerifyDetached(jws string, payload []byte, keys JWKS) error {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return ErrorInvalidSignature
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrorInvalidSignature
+	}
+	header := detachedHeader{}
+	if err = json.Unmarshal(raw, &header); err != nil || header.Typ != DetachedType {
+		return ErrorInvalidSignature
+	}
+	var jwk *JWK
+	for i := range keys.Keys {
+		if keys.Keys[i].Kid == header.Kid {
+			jwk = &keys.Keys[i]
+		}
+	}
+	//the key decides the algorithm, never the header
+	if jwk == nil || jwk.Alg != header.Alg {
+		return ErrorUnknownKey
+	}
+	public, err := jwk.PublicKey()
+	if err != nil {
+		return err
+	}
+	method := jwt.GetSigningMethod(jwk.Alg)
+	if method == nil {
+		return ErrorUnknownKey
+	}
+	if err = method.Verify(parts[0]+"."+base64.RawURLEncoding.EncodeToString(payload), parts[2], public); err != nil {
+		return ErrorInvalidSignature
+	}
+	return nil
+}
+
+// PublicKey - the key the JWK describes, in the type jwt verifies with
+func (j JWK) PublicKey() (interface{}, error) {
+	x, err := base64.RawURLEncoding.DecodeString(j.X)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case j.Kty == "OKP" && j.Crv == "Ed25519" && len(x) == ed25519.PublicKeySize:
+		return ed25519.PublicKey(x), nil
+	case j.Kty == "EC" && j.Crv == "P-256":
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !public.Curve.IsOnCurve(public.X, public.Y) {
+			return nil, ErrorUnknownKey
+		}
+		return public, nil
+	}
+	return nil, ErrorUnknownKey
+}
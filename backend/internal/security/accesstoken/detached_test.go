@@ -0,0 +1,53 @@
+package accesstoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignDetached(t *testing.T) {
+	dir := t.TempDir()
+	ecPrivate, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(ecPrivate)
+	writeKey(t, dir, "ec", "PRIVATE KEY", der)
+	ecKeyring, err := LoadKeyring(dir, "ec")
+	assert.Nil(t, err)
+
+	payload := []byte(`{"user_id":"7"}`)
+	for _, k := range []*Keyring{NewEphemeralKeyring(), ecKeyring} {
+		SetKeyring(k)
+		jws, err := SignDetached(payload)
+		assert.Nil(t, err)
+		assert.Nil(t, VerifyDetached(jws, payload, PublicKeys()))
+
+		assert.ErrorIs(t, VerifyDetached(jws, []byte(`{"user_id":"8"}`), PublicKeys()), ErrorInvalidSignature)
+		//a key we didn't publish
+		other := NewEphemeralKeyring()
+		assert.Error(t, VerifyDetached(jws, payload, JWKS{Keys: []JWK{}}))
+		otherJWS, _ := other.signDetached(payload)
+		assert.Error(t, VerifyDetached(otherJWS, payload, PublicKeys()))
+	}
+	assert.ErrorIs(t, VerifyDetached("not-a-jws", payload, PublicKeys()), ErrorInvalidSignature)
+}
+
+func TestSignDetached_NotAnAccessToken(t *testing.T) {
+	SetKeyring(NewEphemeralKeyring())
+	now := time.Now().Unix()
+	claims := fmt.Sprintf(`{"aud":"7","sid":"1","iat":%d,"nbf":%d,"exp":%d}`, now, now, now+3600)
+	jws, err := SignDetached([]byte(claims))
+	assert.Nil(t, err)
+	//put the payload back in, the signature is valid for the claims
+	parts := strings.Split(jws, ".")
+	token := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + "." + parts[2]
+	_, err = Validate(token)
+	assert.Error(t, err)
+}
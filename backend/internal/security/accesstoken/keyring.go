@@ -0,0 +1,163 @@
+package accesstoken
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var ErrorUnknownKey = errors.New("unknown signing key")
+
+// signingKey - one key of the keyring, retiring keys have no private part,
+// they only verify tokens issued before the rotation
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+// Keyring - holds the active key used to sign new tokens and every key
+// whose tokens are still accepted
+type Keyring struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+// LoadKeyring - loads every <kid>.pem in dir, private keys are PKCS8 and public keys PKIX,
+// Ed25519 keys sign with EdDSA and P-256 keys with ES256
+func LoadKeyring(dir, activeKid string) (*Keyring, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	k := &Keyring{keys: make(map[string]*signingKey)}
+	for _, f := range files {
+		raw, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		kid := strings.TrimSuffix(filepath.Base(f), ".pem")
+		key, err := parseKey(kid, raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		k.keys[kid] = key
+	}
+	active, ok := k.keys[activeKid]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found in %s", activeKid, dir)
+	}
+	if active.private == nil {
+		return nil, fmt.Errorf("active key %q has no private key", activeKid)
+	}
+	k.active = active
+	return k, nil
+}
+
+// NewEphemeralKeyring - a single in memory Ed25519 key, tokens die with the process, dev only
+func NewEphemeralKeyring() *Keyring {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	key := &signingKey{
+		kid:     "ephemeral",
+		method:  jwt.SigningMethodEdDSA,
+		private: private,
+		public:  public,
+	}
+	return &Keyring{
+		active: key,
+		keys:   map[string]*signingKey{key.kid: key},
+	}
+}
+
+func parseKey(kid string, raw []byte) (*signingKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no pem block")
+	}
+	key := &signingKey{kid: kid}
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.private = private
+		switch p := private.(type) {
+		case ed25519.PrivateKey:
+			key.public = p.Public()
+		case *ecdsa.PrivateKey:
+			key.public = &p.PublicKey
+		}
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.public = public
+	default:
+		return nil, fmt.Errorf("unsupported pem block %s", block.Type)
+	}
+
+	switch p := key.public.(type) {
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	case *ecdsa.PublicKey:
+		if p.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 ecdsa keys are supported")
+		}
+		key.method = jwt.SigningMethodES256
+	default:
+		return nil, errors.New("only Ed25519 and P-256 keys are supported")
+	}
+	return key, nil
+}
+
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.active.method, claims)
+	token.Header["kid"] = k.active.kid
+	return token.SignedString(k.active.private)
+}
+
+// keyFunc - picks the verification key by kid and refuses any other algorithm than the key's,
+// only tokens made by jwt are accepted, not detached signatures made with the same keys
+func (k *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	if typ, _ := token.Header["typ"].(string); typ != "JWT" {
+		return nil, fmt.Errorf("unexpected token type: %v", token.Header["typ"])
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, ErrorUnknownKey
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+func (k *Keyring) sortedKeys() []*signingKey {
+	keys := make([]*signingKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].kid < keys[j].kid
+	})
+	return keys
+}
@@ -0,0 +1,75 @@
+package accesstoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"shogun/config"
+	"shogun/internal/model/role"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeKey(t *testing.T, dir, kid, blockType string, der []byte) {
+	t.Helper()
+	raw := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, kid+".pem"), raw, 0600))
+}
+
+func TestKeyringRotation(t *testing.T) {
+	config.Cfg.AccessTokenMinutes = 15
+	dir := t.TempDir()
+
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edPrivate)
+	writeKey(t, dir, "2024-01", "PRIVATE KEY", der)
+
+	ecPrivate, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ = x509.MarshalPKCS8PrivateKey(ecPrivate)
+	writeKey(t, dir, "2024-02", "PRIVATE KEY", der)
+
+	k, err := LoadKeyring(dir, "2024-01")
+	assert.Nil(t, err)
+	SetKeyring(k)
+	oldToken, _ := GenerateTokenForUser(7, 3, role.None)
+
+	//rotate, old key is kept as public only
+	der, _ = x509.MarshalPKIXPublicKey(edPublic)
+	writeKey(t, dir, "2024-01", "PUBLIC KEY", der)
+	k, err = LoadKeyring(dir, "2024-02")
+	assert.Nil(t, err)
+	SetKeyring(k)
+
+	newToken, _ := GenerateTokenForUser(7, 4, role.Admin)
+	claims, err := Validate(newToken)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), claims.SessionID)
+	assert.Equal(t, role.Admin, claims.Role)
+
+	claims, err = Validate(oldToken)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), claims.UserID)
+
+	jwks := PublicKeys()
+	assert.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+	assert.Equal(t, "ES256", jwks.Keys[1].Alg)
+
+	//retired key removed, its tokens stop working
+	assert.Nil(t, os.Remove(filepath.Join(dir, "2024-01.pem")))
+	k, err = LoadKeyring(dir, "2024-02")
+	assert.Nil(t, err)
+	SetKeyring(k)
+	_, err = Validate(oldToken)
+	assert.NotNil(t, err)
+
+	//active key has to exist
+	_, err = LoadKeyring(dir, "missing")
+	assert.NotNil(t, err)
+}
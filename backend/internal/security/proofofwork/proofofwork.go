@@ -0,0 +1,33 @@
+package proofofwork
+
+import (
+	"crypto/sha256"
+	"math/bits"
+)
+
+// MaxDifficulty - above this a phone would take minutes to find a solution
+const MaxDifficulty = 28
+
+// Verify - the solution is valid when sha256("<challenge>:<solution>") starts with
+// at least difficulty zero bits, clients try counters until one works
+func Verify(challenge, solution string, difficulty int) bool {
+	if difficulty <= 0 {
+		return true
+	}
+	if solution == "" || len(solution) > 64 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(challenge + ":" + solution))
+	return leadingZeroBits(sum[:]) >= difficulty
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, v := range b {
+		if v != 0 {
+			return n + bits.LeadingZeros8(v)
+		}
+		n += 8
+	}
+	return n
+}
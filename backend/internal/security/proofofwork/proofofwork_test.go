@@ -0,0 +1,46 @@
+package proofofwork
+
+import (
+	"strconv"
+	"testing"
+)
+
+func solve(challenge string, difficulty int) string {
+	for i := 0; ; i++ {
+		s := strconv.Itoa(i)
+		if Verify(challenge, s, difficulty) {
+			return s
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	solution := solve("nonce", 12)
+	if !Verify("nonce", solution, 12) {
+		t.Fatal("solution should verify")
+	}
+	if Verify("other-nonce", solution, 12) && Verify("other-nonce", solution, 16) {
+		t.Error("solution shouldn't carry over to other challenges")
+	}
+	if Verify("nonce", "", 1) {
+		t.Error("empty solution accepted")
+	}
+	if !Verify("nonce", "", 0) {
+		t.Error("difficulty 0 should accept anything")
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	cases := map[int][]byte{
+		0:  {0x80},
+		7:  {0x01},
+		8:  {0x00, 0xff},
+		12: {0x00, 0x08},
+		16: {0x00, 0x00},
+	}
+	for want, b := range cases {
+		if got := leadingZeroBits(b); got != want {
+			t.Errorf("%x: got %d want %d", b, got, want)
+		}
+	}
+}
@@ -8,14 +8,27 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-var ErrorAccountNotFound = errors.New("account not found")
+var (
+	ErrorAccountNotFound      = errors.New("account not found")
+	ErrorAccountAlreadyLinked = errors.New("account already linked")
+	ErrorCannotUnlinkPrimary  = errors.New("primary account can't be unlinked")
+	ErrorLastAccount          = errors.New("user must keep at least one account")
+)
 
 type Store interface {
 	CreateAccount(acc *account.Account) error
 	CreateAccountNoCommit(tx *sqlx.Tx, acc *account.Account) error
 	GetAccount(address string, chain chain.Chain) (*account.Account, error)
 	GetUserIDForAddress(address string, chain chain.Chain) (int64, error)
+	// LinkAccounts - links all the accounts to the user or none of them
 	LinkAccounts(userID int64, accounts []account.Account) error
+	// UnlinkAccount - removes a non primary account, the user always keeps at least one
+	UnlinkAccount(userID int64, address string, chain chain.Chain) error
+	// SetPrimary - moves the login key of the user to one of their accounts
+	SetPrimary(userID int64, address string, chain chain.Chain) error
+	GetPrimary(userID int64) (*account.Account, error)
 	DoesAccountExist(address string, chain chain.Chain) (bool, error)
+	// GetByUserID - all accounts of the user with their link proofs, oldest first
+	GetByUserID(userID int64) ([]account.Account, error)
 	GetSimpleByUserID(userID int64) ([]account.Simple, error)
 }
@@ -3,6 +3,7 @@ package accountstore
 import (
 	"database/sql"
 	"errors"
+	"shogun/internal/data"
 	"shogun/internal/model/account"
 	"shogun/internal/model/chain"
 	"time"
@@ -38,10 +39,12 @@ func (sas *SqlStore) CreateAccount(acc *account.Account) error {
 	if err := sas.checkBeforeCreate(acc); err != nil {
 		return err
 	}
-	res, err := sas.db.NamedQuery("INSERT INTO shogun.account(address,user_id,chain,signature,created_at) VALUES (:address,:user_id,:chain,:signature,:created_at) RETURNING id", acc)
+	res, err := sas.db.NamedQuery("INSERT INTO shogun.account(address,user_id,chain,signature,proof_signature,linked_by,linked_by_chain,link_nonce,is_primary,created_at) VALUES (:address,:user_id,:chain,:signature,:proof_signature,:linked_by,:linked_by_chain,:link_nonce,:is_primary,:created_at) RETURNING id", acc)
 	if err != nil {
 		return err
 	}
+	//an open result keeps the connection busy, the next query in the tx would fail
+	defer res.Close()
 	if res.Next() {
 		err = res.Scan(&acc.ID)
 	}
@@ -52,10 +55,12 @@ func (sas *SqlStore) CreateAccountNoCommit(tx *sqlx.Tx, acc *account.Account) er
 	if err := sas.checkBeforeCreate(acc); err != nil {
 		return err
 	}
-	res, err := tx.NamedQuery("INSERT INTO shogun.account(address,user_id,chain,signature,created_at) VALUES (:address,:user_id,:chain,:signature,:created_at) RETURNING id", acc)
+	res, err := tx.NamedQuery("INSERT INTO shogun.account(address,user_id,chain,signature,proof_signature,linked_by,linked_by_chain,link_nonce,is_primary,created_at) VALUES (:address,:user_id,:chain,:signature,:proof_signature,:linked_by,:linked_by_chain,:link_nonce,:is_primary,:created_at) RETURNING id", acc)
 	if err != nil {
 		return err
 	}
+	//an open result keeps the connection busy, the next query in the tx would fail
+	defer res.Close()
 	if res.Next() {
 		err = res.Scan(&acc.ID)
 	}
@@ -75,24 +80,119 @@ func (sas *SqlStore) GetAccount(address string, chain chain.Chain) (*account.Acc
 }
 
 func (sas *SqlStore) LinkAccounts(userID int64, accounts []account.Account) error {
+	tx, err := sas.db.Beginx()
+	if err != nil {
+		return err
+	}
 	for i := range accounts {
 		acc := &accounts[i]
 		acc.UserID = userID
-		acc.CreatedAt = time.Now()
-	}
-	//
-	//TODO
-	//tx, err := sas.writeDB.Beginx()
-	//if err != nil {
-	//	return err
-	//}
-	//for _, acc := range accounts {
-	//
-	//}
+		acc.IsPrimary = false
+		err = sas.CreateAccountNoCommit(tx, acc)
+		if err != nil {
+			_ = tx.Rollback()
+			if data.IsUniqueViolation(err) {
+				return ErrorAccountAlreadyLinked
+			}
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// lockUserAccounts - locks all accounts of the user for the rest of the transaction
+// so concurrent unlink and set primary calls can't leave the user without a login key
+func lockUserAccounts(tx *sqlx.Tx, userID int64) ([]account.Account, error) {
+	accounts := make([]account.Account, 0)
+	err := tx.Select(&accounts, "SELECT * FROM shogun.account WHERE user_id = $1 FOR UPDATE", userID)
+	return accounts, err
+}
 
+func findAccount(accounts []account.Account, address string, chain chain.Chain) *account.Account {
+	for i := range accounts {
+		if accounts[i].Address == address && accounts[i].Chain == chain {
+			return &accounts[i]
+		}
+	}
 	return nil
 }
 
+func (sas *SqlStore) UnlinkAccount(userID int64, address string, chain chain.Chain) error {
+	tx, err := sas.db.Beginx()
+	if err != nil {
+		return err
+	}
+	accounts, err := lockUserAccounts(tx, userID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	acc := findAccount(accounts, address, chain)
+	if acc == nil {
+		_ = tx.Rollback()
+		return ErrorAccountNotFound
+	}
+	if acc.IsPrimary {
+		_ = tx.Rollback()
+		return ErrorCannotUnlinkPrimary
+	}
+	if len(accounts) <= 1 {
+		_ = tx.Rollback()
+		return ErrorLastAccount
+	}
+	_, err = tx.Exec("DELETE FROM shogun.account WHERE id = $1", acc.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (sas *SqlStore) SetPrimary(userID int64, address string, chain chain.Chain) error {
+	tx, err := sas.db.Beginx()
+	if err != nil {
+		return err
+	}
+	accounts, err := lockUserAccounts(tx, userID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	acc := findAccount(accounts, address, chain)
+	if acc == nil {
+		_ = tx.Rollback()
+		return ErrorAccountNotFound
+	}
+	if acc.IsPrimary {
+		_ = tx.Rollback()
+		return nil
+	}
+	//two statements, the unique index on primary accounts is checked per row
+	_, err = tx.Exec("UPDATE shogun.account SET is_primary = FALSE WHERE user_id = $1 AND is_primary", userID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("UPDATE shogun.account SET is_primary = TRUE WHERE id = $1", acc.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (sas *SqlStore) GetPrimary(userID int64) (*account.Account, error) {
+	acc := account.New()
+	err := sas.db.Get(acc, "SELECT * FROM shogun.account WHERE user_id = $1 AND is_primary", userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorAccountNotFound
+		}
+		return nil, err
+	}
+	return acc, nil
+}
+
 func (sas *SqlStore) DoesAccountExist(address string, chain chain.Chain) (bool, error) {
 	var count int
 	err := sas.db.Get(&count, "SELECT COUNT(*) FROM shogun.account WHERE address = $1 AND chain = $2", address, chain)
@@ -114,9 +214,18 @@ func (sas *SqlStore) GetUserIDForAddress(address string, chain chain.Chain) (int
 	return userID, nil
 }
 
+func (sas *SqlStore) GetByUserID(userID int64) ([]account.Account, error) {
+	accounts := make([]account.Account, 0)
+	err := sas.db.Select(&accounts, "SELECT * FROM shogun.account WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
 func (sas *SqlStore) GetSimpleByUserID(userID int64) ([]account.Simple, error) {
 	accounts := make([]account.Simple, 0)
-	err := sas.db.Select(&accounts, "SELECT address,chain,signature FROM shogun.account WHERE user_id = $1", userID)
+	err := sas.db.Select(&accounts, "SELECT address,chain,signature,is_primary FROM shogun.account WHERE user_id = $1", userID)
 	if err != nil {
 		return nil, err
 	}
@@ -134,3 +134,111 @@ func TestSqlStore_GetSimpleByUserID(t *testing.T) {
 		assert.Equal(t, accounts[i].Chain, simpleAcc.Chain)
 	}
 }
+
+func TestSqlStore_LinkAccounts(t *testing.T) {
+	db := testutil.GetSqlDB()
+	store := NewSqlStore(db)
+
+	userID := int64(1)
+	primary := &account.Account{Chain: chain.Solana, Address: "link-primary", UserID: userID, IsPrimary: true}
+	err := store.CreateAccount(primary)
+	assert.NoError(t, err)
+
+	err = store.LinkAccounts(userID, []account.Account{
+		{Chain: chain.Sui, Address: "link-sui"},
+		{Chain: chain.Solana, Address: "link-solana"},
+	})
+	assert.NoError(t, err)
+
+	// Linking an address that is already linked fails and links nothing
+	err = store.LinkAccounts(userID, []account.Account{
+		{Chain: chain.Sui, Address: "link-sui-2"},
+		{Chain: chain.Sui, Address: "link-sui"},
+	})
+	assert.Equal(t, ErrorAccountAlreadyLinked, err)
+	exists, err := store.DoesAccountExist("link-sui-2", chain.Sui)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	accounts, err := store.GetSimpleByUserID(userID)
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 3)
+}
+
+func TestSqlStore_LinkAccounts_SeveralInOneCall(t *testing.T) {
+	db := testutil.GetSqlDB()
+	store := NewSqlStore(db)
+
+	userID := int64(4)
+	primary := &account.Account{Chain: chain.Solana, Address: "many-primary", UserID: userID, IsPrimary: true}
+	err := store.CreateAccount(primary)
+	assert.NoError(t, err)
+
+	// Every insert runs on the same transaction
+	linked := []account.Account{
+		{Chain: chain.Sui, Address: "many-sui"},
+		{Chain: chain.Solana, Address: "many-solana"},
+		{Chain: chain.Solana, Address: "many-solana-2"},
+	}
+	err = store.LinkAccounts(userID, linked)
+	assert.NoError(t, err)
+	for _, acc := range linked {
+		assert.NotZero(t, acc.ID)
+		created, err := store.GetAccount(acc.Address, acc.Chain)
+		assert.NoError(t, err)
+		assert.Equal(t, userID, created.UserID)
+	}
+}
+
+func TestSqlStore_UnlinkAccount(t *testing.T) {
+	db := testutil.GetSqlDB()
+	store := NewSqlStore(db)
+
+	userID := int64(2)
+	primary := &account.Account{Chain: chain.Solana, Address: "unlink-primary", UserID: userID, IsPrimary: true}
+	err := store.CreateAccount(primary)
+	assert.NoError(t, err)
+
+	// The only account is primary and can't be removed
+	err = store.UnlinkAccount(userID, primary.Address, primary.Chain)
+	assert.Equal(t, ErrorCannotUnlinkPrimary, err)
+
+	err = store.LinkAccounts(userID, []account.Account{{Chain: chain.Sui, Address: "unlink-sui"}})
+	assert.NoError(t, err)
+
+	// Another user can't unlink it
+	err = store.UnlinkAccount(userID+1, "unlink-sui", chain.Sui)
+	assert.Equal(t, ErrorAccountNotFound, err)
+
+	err = store.UnlinkAccount(userID, "unlink-sui", chain.Sui)
+	assert.NoError(t, err)
+	_, err = store.GetAccount("unlink-sui", chain.Sui)
+	assert.Equal(t, ErrorAccountNotFound, err)
+}
+
+func TestSqlStore_SetPrimary(t *testing.T) {
+	db := testutil.GetSqlDB()
+	store := NewSqlStore(db)
+
+	userID := int64(3)
+	primary := &account.Account{Chain: chain.Solana, Address: "primary-old", UserID: userID, IsPrimary: true}
+	err := store.CreateAccount(primary)
+	assert.NoError(t, err)
+	err = store.LinkAccounts(userID, []account.Account{{Chain: chain.Sui, Address: "primary-new"}})
+	assert.NoError(t, err)
+
+	err = store.SetPrimary(userID, "primary-new", chain.Sui)
+	assert.NoError(t, err)
+
+	current, err := store.GetPrimary(userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "primary-new", current.Address)
+
+	old, err := store.GetAccount("primary-old", chain.Solana)
+	assert.NoError(t, err)
+	assert.False(t, old.IsPrimary)
+
+	// The old primary can now be unlinked
+	err = store.UnlinkAccount(userID, "primary-old", chain.Solana)
+	assert.NoError(t, err)
+}
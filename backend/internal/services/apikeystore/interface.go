@@ -0,0 +1,26 @@
+package apikeystore
+
+import (
+	"errors"
+	"shogun/internal/model/apikey"
+	"time"
+)
+
+var ErrorKeyNotFound = errors.New("api key not found")
+
+type Store interface {
+	// Create - stores the key, the secret hash has to be set already
+	Create(k *apikey.Key) error
+	Get(id int64) (*apikey.Key, error)
+	// GetBySecretHash - the key of a request, revoked keys are returned too
+	GetBySecretHash(hash string) (*apikey.Key, error)
+	// GetForUser - every key of the user, revoked ones included, newest first
+	GetForUser(userID int64) ([]apikey.Key, error)
+	CountActiveForUser(userID int64) (int, error)
+	// Revoke - revokes a key of the user, userID 0 revokes it whoever owns it
+	Revoke(id, userID int64) (*apikey.Key, error)
+	// AddUsage - adds request counts per key for the day and marks the keys used
+	AddUsage(day time.Time, counts map[int64]int64) error
+	// GetUsage - daily request counts of the key for the last days, newest first
+	GetUsage(id int64, days int) ([]apikey.Usage, error)
+}
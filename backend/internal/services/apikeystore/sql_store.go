@@ -0,0 +1,127 @@
+package apikeystore
+
+import (
+	"database/sql"
+	"errors"
+	"shogun/internal/model/apikey"
+	"shogun/internal/utils/text"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SqlStore struct {
+	db *sqlx.DB
+}
+
+func NewSqlStore(db *sqlx.DB) *SqlStore {
+	return &SqlStore{
+		db: db,
+	}
+}
+
+func (s *SqlStore) Create(k *apikey.Key) error {
+	if k.UserID == 0 {
+		return errors.New("user id missing")
+	}
+	if k.SecretHash == "" {
+		return errors.New("secret hash missing")
+	}
+	k.Name = text.Truncate(k.Name, apikey.NameMaxLength)
+	k.CreatedAt = time.Now()
+	rows, err := s.db.NamedQuery(`INSERT INTO shogun.api_key (user_id, name, prefix, secret_hash, scopes, hourly_quota, created_by, created_at)
+		VALUES (:user_id, :name, :prefix, :secret_hash, :scopes, :hourly_quota, :created_by, :created_at) RETURNING id`, k)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		err = rows.Scan(&k.ID)
+	}
+	return err
+}
+
+func (s *SqlStore) Get(id int64) (*apikey.Key, error) {
+	return s.getOne("SELECT * FROM shogun.api_key WHERE id = $1", id)
+}
+
+func (s *SqlStore) GetBySecretHash(hash string) (*apikey.Key, error) {
+	return s.getOne("SELECT * FROM shogun.api_key WHERE secret_hash = $1", hash)
+}
+
+func (s *SqlStore) getOne(query string, arg any) (*apikey.Key, error) {
+	k := apikey.New()
+	err := s.db.Get(k, query, arg)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorKeyNotFound
+		}
+		return nil, err
+	}
+	return k, nil
+}
+
+func (s *SqlStore) GetForUser(userID int64) ([]apikey.Key, error) {
+	keys := make([]apikey.Key, 0)
+	err := s.db.Select(&keys, "SELECT * FROM shogun.api_key WHERE user_id = $1 ORDER BY id DESC", userID)
+	return keys, err
+}
+
+func (s *SqlStore) CountActiveForUser(userID int64) (int, error) {
+	var count int
+	err := s.db.Get(&count, "SELECT COUNT(*) FROM shogun.api_key WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	return count, err
+}
+
+func (s *SqlStore) Revoke(id, userID int64) (*apikey.Key, error) {
+	k := apikey.New()
+	err := s.db.Get(k, `UPDATE shogun.api_key SET revoked_at = NOW()
+		WHERE id = $1 AND ($2 = 0 OR user_id = $2) AND revoked_at IS NULL RETURNING *`, id, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorKeyNotFound
+		}
+		return nil, err
+	}
+	return k, nil
+}
+
+func (s *SqlStore) AddUsage(day time.Time, counts map[int64]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(counts))
+	requests := make([]int64, 0, len(counts))
+	for id, n := range counts {
+		ids = append(ids, id)
+		requests = append(requests, n)
+	}
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	//keys deleted in the meantime are skipped by the join
+	_, err = tx.Exec(`INSERT INTO shogun.api_key_usage (key_id, day, requests)
+		SELECT u.id, $1::DATE, u.requests FROM unnest($2::BIGINT[], $3::BIGINT[]) AS u(id, requests)
+		JOIN shogun.api_key k ON k.id = u.id
+		ON CONFLICT (key_id, day) DO UPDATE SET requests = shogun.api_key_usage.requests + EXCLUDED.requests`,
+		day.UTC().Format(time.DateOnly), ids, requests)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE shogun.api_key SET last_used_at = NOW() WHERE id = ANY($1::BIGINT[])", ids)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SqlStore) GetUsage(id int64, days int) ([]apikey.Usage, error) {
+	usage := make([]apikey.Usage, 0)
+	err := s.db.Select(&usage, `SELECT day, requests FROM shogun.api_key_usage
+		WHERE key_id = $1 AND day > CURRENT_DATE - $2::INT ORDER BY day DESC`, id, days)
+	return usage, err
+}
@@ -0,0 +1,74 @@
+package apikeystore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dreson4/graceful/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// UsageCounter - counts key requests in memory and adds them to the daily usage in batches
+type UsageCounter struct {
+	store    Store
+	interval time.Duration
+	pending  map[time.Time]map[int64]int64
+	mu       sync.Mutex
+}
+
+func NewUsageCounter(store Store, interval time.Duration) *UsageCounter {
+	uc := &UsageCounter{
+		store:    store,
+		interval: interval,
+		pending:  make(map[time.Time]map[int64]int64),
+	}
+	go uc.run()
+	return uc
+}
+
+func (uc *UsageCounter) Count(keyID int64) {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	uc.mu.Lock()
+	counts, ok := uc.pending[day]
+	if !ok {
+		counts = make(map[int64]int64)
+		uc.pending[day] = counts
+	}
+	counts[keyID]++
+	uc.mu.Unlock()
+}
+
+func (uc *UsageCounter) run() {
+	ticker := time.NewTicker(uc.interval)
+	done := make(chan struct{})
+	graceful.OnShutdown(func() {
+		close(done)
+		uc.flush()
+	})
+	for {
+		select {
+		case <-ticker.C:
+			uc.flush()
+		case <-done:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+func (uc *UsageCounter) flush() {
+	uc.mu.Lock()
+	if len(uc.pending) == 0 {
+		uc.mu.Unlock()
+		return
+	}
+	batch := uc.pending
+	uc.pending = make(map[time.Time]map[int64]int64)
+	uc.mu.Unlock()
+
+	for day, counts := range batch {
+		if err := uc.store.AddUsage(day, counts); err != nil {
+			log.Err(err).Int("keys", len(counts)).Msg("failed to add api key usage")
+		}
+	}
+}
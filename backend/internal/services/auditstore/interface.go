@@ -0,0 +1,22 @@
+package auditstore
+
+import (
+	"shogun/internal/model/audit"
+	"time"
+)
+
+// Recorder - what controllers need, writing only
+type Recorder interface {
+	Record(ev *audit.Event) error
+}
+
+type Store interface {
+	Recorder
+	// GetForUser - events of the user newest first, before is an event id cursor, 0 for the newest
+	GetForUser(userID, before int64, limit int) ([]audit.Event, error)
+	Query(f audit.Filter) ([]audit.Event, error)
+	// SignupVelocity - sign ups and blocked sign ups per hour since, newest first
+	SignupVelocity(since time.Time) ([]audit.HourCount, error)
+	// SignupSources - the ips with the most sign up attempts since
+	SignupSources(since time.Time, limit int) ([]audit.SourceCount, error)
+}
@@ -0,0 +1,97 @@
+package auditstore
+
+import (
+	"errors"
+	"fmt"
+	"shogun/internal/model/audit"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SqlStore struct {
+	db *sqlx.DB
+}
+
+func NewSqlStore(db *sqlx.DB) *SqlStore {
+	return &SqlStore{
+		db: db,
+	}
+}
+
+func (s *SqlStore) Record(ev *audit.Event) error {
+	if ev.UserID == 0 && ev.ActorID == 0 && ev.Target == "" {
+		return errors.New("audit event without user, actor or target")
+	}
+	ev.Truncate()
+	ev.CreatedAt = time.Now()
+	rows, err := s.db.NamedQuery(`INSERT INTO shogun.audit_event (user_id, actor_id, action, target, ip, user_agent, payload, created_at)
+		VALUES (:user_id, :actor_id, :action, :target, :ip, :user_agent, :payload, :created_at) RETURNING id`, ev)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		err = rows.Scan(&ev.ID)
+	}
+	return err
+}
+
+func (s *SqlStore) GetForUser(userID, before int64, limit int) ([]audit.Event, error) {
+	return s.Query(audit.Filter{UserID: userID, Before: before, Limit: limit})
+}
+
+func (s *SqlStore) Query(f audit.Filter) ([]audit.Event, error) {
+	where := make([]string, 0, 4)
+	args := make([]any, 0, 5)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.UserID != 0 {
+		add("user_id = $%d", f.UserID)
+	}
+	if f.ActorID != 0 {
+		add("actor_id = $%d", f.ActorID)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.Before != 0 {
+		add("id < $%d", f.Before)
+	}
+	query := "SELECT * FROM shogun.audit_event"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, f.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	events := make([]audit.Event, 0)
+	if err := s.db.Select(&events, query, args...); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (s *SqlStore) SignupVelocity(since time.Time) ([]audit.HourCount, error) {
+	hours := make([]audit.HourCount, 0)
+	err := s.db.Select(&hours, `SELECT date_trunc('hour', created_at) AS hour,
+			COUNT(*) FILTER (WHERE action = $1) AS sign_ups,
+			COUNT(*) FILTER (WHERE action = $2) AS blocked,
+			COUNT(DISTINCT ip) FILTER (WHERE action = $1) AS ips
+		FROM shogun.audit_event WHERE action IN ($1, $2) AND created_at >= $3
+		GROUP BY 1 ORDER BY 1 DESC`, audit.ActionSignUp, audit.ActionSignupBlocked, since)
+	return hours, err
+}
+
+func (s *SqlStore) SignupSources(since time.Time, limit int) ([]audit.SourceCount, error) {
+	sources := make([]audit.SourceCount, 0)
+	err := s.db.Select(&sources, `SELECT ip AS source,
+			COUNT(*) FILTER (WHERE action = $1) AS sign_ups,
+			COUNT(*) FILTER (WHERE action = $2) AS blocked
+		FROM shogun.audit_event WHERE action IN ($1, $2) AND created_at >= $3
+		GROUP BY ip ORDER BY COUNT(*) DESC LIMIT $4`, audit.ActionSignUp, audit.ActionSignupBlocked, since, limit)
+	return sources, err
+}
@@ -3,61 +3,139 @@ package autocomplete
 import (
 	"shogun/internal/model/user"
 	"shogun/internal/services/usercache"
+	"shogun/internal/services/userinfosync"
 	"shogun/internal/services/userstore"
+	"shogun/internal/services/visibility"
+	"sync"
 
-	trie "github.com/Vivino/go-autocomplete-trie"
 	"github.com/rs/zerolog/log"
 )
 
 type Trie struct {
-	root      *trie.Trie
-	userStore userstore.Store
-	userCache usercache.Cache
+	mu        sync.RWMutex
+	tree      *prefixTree
+	usernames map[int64]string //the username each user is indexed under, to remove it when it changes
+	loading   bool
+	removed   map[int64]bool //users deleted while loading, the load must not bring them back
+
+	userStore  userstore.Store
+	userCache  usercache.SimpleCache
+	userSync   userinfosync.Service
+	visibility *visibility.Policy
 }
 
-func NewTrieAutocomplete(userStore userstore.Store, cache usercache.Cache) *Trie {
-	t := trie.New().
-		WithoutFuzzy().     //remove if you want fuzzy search
-		WithNormalisation() //remove if you want case-sensitive search
+func NewTrieAutocomplete(userStore userstore.Store, cache usercache.SimpleCache, userSync userinfosync.Service, policy *visibility.Policy) *Trie {
 	return &Trie{
-		root:      t,
-		userStore: userStore,
-		userCache: cache,
+		tree:       newPrefixTree(),
+		usernames:  make(map[int64]string),
+		removed:    make(map[int64]bool),
+		userStore:  userStore,
+		userCache:  cache,
+		userSync:   userSync,
+		visibility: policy,
 	}
 }
 
-func (t *Trie) Run() {
-	t.loadDbUsers()
+func (t *Trie) Run() error {
+	t.loading = true
+	//listen first so changes made while loading aren't missed
+	err := t.userSync.Listen(func(userID int64, updatable user.Updatable) {
+		if updatable.Username != nil {
+			t.set(userID, *updatable.Username)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	err = t.userSync.ListenDeleted(func(deleted user.Deleted) {
+		t.remove(deleted.ID)
+	})
+	if err != nil {
+		return err
+	}
+	go t.loadDbUsers()
+	return nil
 }
 
 func (t *Trie) loadDbUsers() {
-	err := t.userStore.GetAllUsernames(func(username string) {
-		t.insert(username)
+	count := 0
+	err := t.userStore.GetAllUsernames(func(userID int64, username string) {
+		t.load(userID, username)
+		count++
 	})
 	if err != nil {
 		log.Err(err).Msg("Failed to load users")
 	}
+	t.mu.Lock()
+	t.loading = false
+	t.removed = make(map[int64]bool)
+	t.mu.Unlock()
+	log.Info().Int("count", count).Msg("autocomplete usernames loaded")
 }
 
-func (t *Trie) insert(username string) {
-	t.root.Insert(username)
+// load - adds a username read from the db unless a newer change already reached us
+func (t *Trie) load(userID int64, username string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.usernames[userID]; ok || t.removed[userID] {
+		return
+	}
+	t.usernames[userID] = username
+	t.tree.insert(username, userID)
 }
 
-func (t *Trie) Search(prefix string) ([]user.Simple, error) {
-	results := t.root.Search(prefix, maxResults)
-	if len(results) == 0 {
-		return nil, nil
+func (t *Trie) set(userID int64, username string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if old, ok := t.usernames[userID]; ok {
+		t.tree.remove(old, userID)
 	}
-	if len(results) > maxResults {
-		results = results[:maxResults]
+	t.usernames[userID] = username
+	t.tree.insert(username, userID)
+}
+
+func (t *Trie) remove(userID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.loading {
+		t.removed[userID] = true
 	}
-	res := make([]user.Simple, 0, len(results))
-	for _, username := range results {
-		simple, err := t.userCache.GetByUsername(username)
+	old, ok := t.usernames[userID]
+	if !ok {
+		return
+	}
+	delete(t.usernames, userID)
+	t.tree.remove(old, userID)
+}
+
+func (t *Trie) Search(viewerID int64, prefix string, limit int) ([]user.Simple, error) {
+	if limit <= 0 || limit > maxResults {
+		limit = maxResults
+	}
+	//ask for more than needed, some users hide from username search
+	t.mu.RLock()
+	ids := t.tree.search(prefix, limit*2)
+	t.mu.RUnlock()
+	if len(ids) == 0 {
+		return []user.Simple{}, nil
+	}
+	visible, err := t.visibility.Filter(viewerID, ids, visibility.ByUsername)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]user.Simple, 0, limit)
+	for _, id := range ids {
+		if !visible[id] {
+			continue
+		}
+		simple, err := t.userCache.GetByID(id)
 		if err != nil {
 			continue
 		}
 		res = append(res, *simple)
+		if len(res) >= limit {
+			break
+		}
 	}
 	return res, nil
 }
@@ -7,6 +7,8 @@ import (
 const maxResults = 25
 
 type Autocomplete interface {
+	// Run - starts listening for username changes and loads every username in the background
 	Run() error
-	Search(prefix string) []user.Simple
+	// Search - users whose username starts with prefix that viewerID is allowed to find by username
+	Search(viewerID int64, prefix string, limit int) ([]user.Simple, error)
 }
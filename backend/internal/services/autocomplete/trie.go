@@ -0,0 +1,116 @@
+package autocomplete
+
+import (
+	"sort"
+	"strings"
+)
+
+// node - usernames are case-insensitive, so keys are lowercased and userID is set where a username ends
+type node struct {
+	children map[byte]*node
+	userID   int64
+}
+
+func newNode() *node {
+	return &node{children: make(map[byte]*node)}
+}
+
+// maxVisited - most nodes one search looks at, it runs under the read lock on a public route
+const maxVisited = 2048
+
+// prefixTree - not safe for concurrent use, Trie guards it
+// every node has at least one username below it, remove prunes the branches that don't
+type prefixTree struct {
+	root *node
+}
+
+func newPrefixTree() *prefixTree {
+	return &prefixTree{root: newNode()}
+}
+
+func (p *prefixTree) insert(username string, userID int64) {
+	current := p.root
+	key := strings.ToLower(username)
+	for i := 0; i < len(key); i++ {
+		child, ok := current.children[key[i]]
+		if !ok {
+			child = newNode()
+			current.children[key[i]] = child
+		}
+		current = child
+	}
+	current.userID = userID
+}
+
+// remove - drops the username if it still belongs to the user and prunes the branches left without usernames
+func (p *prefixTree) remove(username string, userID int64) {
+	key := strings.ToLower(username)
+	path := make([]*node, 0, len(key)+1)
+	current := p.root
+	path = append(path, current)
+	for i := 0; i < len(key); i++ {
+		child, ok := current.children[key[i]]
+		if !ok {
+			return
+		}
+		current = child
+		path = append(path, current)
+	}
+	if current.userID != userID {
+		return
+	}
+	current.userID = 0
+	for i := len(key); i > 0; i-- {
+		n := path[i]
+		if n.userID != 0 || len(n.children) > 0 {
+			return
+		}
+		delete(path[i-1].children, key[i-1])
+	}
+}
+
+// search - user ids of usernames starting with prefix, shortest usernames first then alphabetical
+// each level only keeps as many branches as results are still missing, every branch ends in a username
+// so that's enough to fill the page, a shorter username in a dropped branch can lose its place
+func (p *prefixTree) search(prefix string, limit int) []int64 {
+	current := p.root
+	key := strings.ToLower(prefix)
+	for i := 0; i < len(key); i++ {
+		child, ok := current.children[key[i]]
+		if !ok {
+			return nil
+		}
+		current = child
+	}
+	res := make([]int64, 0, limit)
+	level := []*node{current}
+	visited := 0
+	for len(level) > 0 && visited < maxVisited {
+		for _, n := range level {
+			if n.userID != 0 {
+				res = append(res, n.userID)
+				if len(res) >= limit {
+					return res
+				}
+			}
+		}
+		visited += len(level)
+		missing := limit - len(res)
+		next := make([]*node, 0, missing)
+		for _, n := range level {
+			keys := make([]byte, 0, len(n.children))
+			for k := range n.children {
+				keys = append(keys, k)
+			}
+			sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+			for _, k := range keys {
+				if len(next) >= missing {
+					break
+				}
+				next = append(next, n.children[k])
+			}
+		}
+		level = next
+	}
+	return res
+}
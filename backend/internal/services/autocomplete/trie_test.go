@@ -0,0 +1,80 @@
+package autocomplete
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixTree_Search(t *testing.T) {
+	tree := newPrefixTree()
+	tree.insert("bobby", 1)
+	tree.insert("Bob", 2)
+	tree.insert("bobcat", 3)
+	tree.insert("alice", 4)
+
+	//shortest first, a username that prefixes another one is still found
+	assert.Equal(t, []int64{2, 1, 3}, tree.search("bob", 10))
+	assert.Equal(t, []int64{2, 1, 3}, tree.search("BOB", 10))
+	assert.Equal(t, []int64{2, 1}, tree.search("b", 2))
+	assert.Equal(t, []int64{4}, tree.search("al", 10))
+	assert.Empty(t, tree.search("carol", 10))
+}
+
+func TestPrefixTree_Remove(t *testing.T) {
+	tree := newPrefixTree()
+	tree.insert("bob", 1)
+	tree.insert("bobby", 2)
+
+	tree.remove("bobby", 2)
+	assert.Equal(t, []int64{1}, tree.search("bo", 10))
+	assert.Empty(t, tree.root.children['b'].children['o'].children['b'].children)
+
+	//bob was taken over by another user, the old owner can't remove it
+	tree.remove("bob", 3)
+	assert.Equal(t, []int64{1}, tree.search("bob", 10))
+	tree.remove("bob", 1)
+	assert.Empty(t, tree.search("b", 10))
+	assert.Empty(t, tree.root.children)
+
+	//removing what isn't there is fine
+	tree.remove("carol", 1)
+}
+
+func TestTrie_UpdatesWinOverLoad(t *testing.T) {
+	tr := NewTrieAutocomplete(nil, nil, nil, nil)
+	tr.loading = true
+
+	//renamed and deleted while the db was being read
+	tr.set(1, "newname")
+	tr.remove(2)
+	tr.load(1, "oldname")
+	tr.load(2, "deleted")
+	tr.load(3, "carol")
+
+	assert.Equal(t, []int64{1}, tr.tree.search("newname", 10))
+	assert.Empty(t, tr.tree.search("oldname", 10))
+	assert.Empty(t, tr.tree.search("deleted", 10))
+	assert.Equal(t, []int64{3}, tr.tree.search("carol", 10))
+
+	tr.set(3, "carla")
+	assert.Empty(t, tr.tree.search("carol", 10))
+	assert.Equal(t, []int64{3}, tr.tree.search("car", 10))
+}
+
+func TestPrefixTree_SearchBounded(t *testing.T) {
+	tree := newPrefixTree()
+	//generated usernames all have the same length, nothing ends before the last level
+	id := int64(1)
+	for _, a := range "abcdefghij" {
+		for _, b := range "abcdefghij" {
+			for _, c := range "abcdefghij" {
+				tree.insert("sh"+string(a)+string(b)+string(c)+"_warrior", id)
+				id++
+			}
+		}
+	}
+	res := tree.search("sh", 5)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, res)
+	assert.Len(t, tree.search("sh", 25), 25)
+}
@@ -0,0 +1,16 @@
+package challengestore
+
+import (
+	"errors"
+	"shogun/internal/model/challenge"
+)
+
+var ErrorChallengeNotFound = errors.New("challenge not found")
+
+type Store interface {
+	Issue(c *challenge.Challenge) error
+	// Get returns the challenge and leaves it in place, for checks that must pass before it's consumed
+	Get(nonce string) (*challenge.Challenge, error)
+	// Consume returns the challenge and removes it, a nonce can only ever be consumed once
+	Consume(nonce string) (*challenge.Challenge, error)
+}
@@ -0,0 +1,105 @@
+package challengestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"shogun/internal/model/challenge"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog/log"
+)
+
+const natsChallengeBucketName = "auth-challenges"
+
+type Nats struct {
+	store jetstream.KeyValue
+}
+
+// NewNats - ttl is how long an unused challenge is kept, the bucket drops it after that
+func NewNats(j jetstream.JetStream, ttl time.Duration) *Nats {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	b, err := j.KeyValue(ctx, natsChallengeBucketName)
+	if err != nil && !errors.Is(err, jetstream.ErrBucketNotFound) {
+		log.Fatal().Err(err).Msg("failed to get challenges bucket")
+	}
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		b, err = j.CreateKeyValue(ctx,
+			jetstream.KeyValueConfig{
+				Bucket:      natsChallengeBucketName,
+				Description: "bucket for single use auth challenges",
+				History:     1,
+				TTL:         ttl,
+				Storage:     jetstream.FileStorage,
+			})
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create challenges bucket")
+		}
+	}
+	return &Nats{store: b}
+}
+
+func (n *Nats) Issue(c *challenge.Challenge) error {
+	jsonData, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = n.store.Create(ctx, c.Nonce, jsonData)
+	return err
+}
+
+func (n *Nats) get(ctx context.Context, nonce string) (jetstream.KeyValueEntry, error) {
+	val, err := n.store.Get(ctx, nonce)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) || errors.Is(err, jetstream.ErrInvalidKey) {
+			return nil, ErrorChallengeNotFound
+		}
+		return nil, err
+	}
+	return val, nil
+}
+
+func decode(val jetstream.KeyValueEntry) (*challenge.Challenge, error) {
+	c := &challenge.Challenge{}
+	if err := json.Unmarshal(val.Value(), c); err != nil {
+		return nil, err
+	}
+	if c.IsExpired() {
+		return nil, ErrorChallengeNotFound
+	}
+	return c, nil
+}
+
+func (n *Nats) Get(nonce string) (*challenge.Challenge, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	val, err := n.get(ctx, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return decode(val)
+}
+
+func (n *Nats) Consume(nonce string) (*challenge.Challenge, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	val, err := n.get(ctx, nonce)
+	if err != nil {
+		return nil, err
+	}
+	//deleting at the revision we read makes consuming atomic, if another
+	//server consumed it first the revision moved on and this fails
+	err = n.store.Delete(ctx, nonce, jetstream.LastRevision(val.Revision()))
+	if err != nil {
+		var apiErr *jetstream.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode == jetstream.JSErrCodeStreamWrongLastSequence {
+			return nil, ErrorChallengeNotFound
+		}
+		return nil, err
+	}
+	return decode(val)
+}
@@ -0,0 +1,40 @@
+package contactstore
+
+import (
+	"errors"
+	"shogun/internal/model/contact"
+)
+
+var (
+	ErrorRequestNotFound = errors.New("contact request not found")
+	ErrorNotContacts     = errors.New("not contacts")
+	ErrorSelf            = errors.New("can't add yourself as a contact")
+	ErrorTooManyPending  = errors.New("too many pending contact requests")
+)
+
+// MaxPendingOutgoing - requests a user can have waiting for an answer, stops one account from asking everybody
+const MaxPendingOutgoing = 100
+
+type Store interface {
+	// Request - asks contactID to become a contact of userID, when contactID already asked userID they
+	// become contacts right away, returns the status the two end up with, ErrorTooManyPending when
+	// userID already has MaxPendingOutgoing requests waiting
+	Request(userID, contactID int64) (contact.Status, error)
+	// Accept - userID accepts the request requesterID sent them
+	Accept(userID, requesterID int64) error
+	// Decline - userID turns down the request requesterID sent them
+	Decline(userID, requesterID int64) error
+	// Remove - ends the contact or cancels a request either of them sent
+	Remove(userID, otherID int64) error
+	// List - contacts of userID by row id newest first, which is when userID sent the request or accepted theirs,
+	// before is the id of the last contact of the previous page
+	List(userID, before int64, limit int) ([]contact.Contact, error)
+	// Incoming - requests sent to userID, newest first
+	Incoming(userID, before int64, limit int) ([]contact.Contact, error)
+	// Outgoing - requests userID sent that are still pending, newest first
+	Outgoing(userID, before int64, limit int) ([]contact.Contact, error)
+	// Mutual - contacts of userID that are contacts of otherID too
+	Mutual(userID, otherID, before int64, limit int) ([]contact.Contact, error)
+	// ContactsAmong - which of otherIDs are contacts of userID
+	ContactsAmong(userID int64, otherIDs []int64) (map[int64]bool, error)
+}
@@ -0,0 +1,230 @@
+package contactstore
+
+import (
+	"database/sql"
+	"errors"
+	"shogun/internal/model/contact"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SqlStore struct {
+	db *sqlx.DB
+}
+
+func NewSqlStore(db *sqlx.DB) *SqlStore {
+	return &SqlStore{
+		db: db,
+	}
+}
+
+// lockPair - serializes changes between the two users until the transaction ends,
+// rows that don't exist yet can't be locked with FOR UPDATE
+func lockPair(tx *sqlx.Tx, userID, otherID int64) error {
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock($1::BIGINT # $2::BIGINT)", userID, otherID)
+	return err
+}
+
+// lockRequester - serializes the pending requests one user sends, so concurrent requests
+// can't go past MaxPendingOutgoing
+func lockRequester(tx *sqlx.Tx, userID int64) error {
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock(2, hashint8($1))", userID)
+	return err
+}
+
+func getContact(tx *sqlx.Tx, userID, contactID int64) (*contact.Contact, error) {
+	c := contact.New()
+	err := tx.Get(c, "SELECT * FROM shogun.contact WHERE user_id = $1 AND contact_id = $2", userID, contactID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// acceptNoCommit - turns the request into a contact both ways
+func acceptNoCommit(tx *sqlx.Tx, requesterID, userID int64) error {
+	res, err := tx.Exec(`UPDATE shogun.contact SET status = $3, updated_at = NOW()
+		WHERE user_id = $1 AND contact_id = $2 AND status = $4`, requesterID, userID, contact.StatusAccepted, contact.StatusPending)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrorRequestNotFound
+	}
+	_, err = tx.Exec(`INSERT INTO shogun.contact(user_id, contact_id, status) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, contact_id) DO UPDATE SET status = EXCLUDED.status, updated_at = NOW()`,
+		userID, requesterID, contact.StatusAccepted)
+	return err
+}
+
+func (s *SqlStore) Request(userID, contactID int64) (contact.Status, error) {
+	if userID == contactID {
+		return "", ErrorSelf
+	}
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return "", err
+	}
+	if err = lockPair(tx, userID, contactID); err != nil {
+		_ = tx.Rollback()
+		return "", err
+	}
+	//asking again changes nothing
+	existing, err := getContact(tx, userID, contactID)
+	if err != nil || existing != nil {
+		_ = tx.Rollback()
+		if existing != nil {
+			return existing.Status, nil
+		}
+		return "", err
+	}
+	reverse, err := getContact(tx, contactID, userID)
+	if err != nil {
+		_ = tx.Rollback()
+		return "", err
+	}
+	if reverse != nil && reverse.Status == contact.StatusPending {
+		if err = acceptNoCommit(tx, contactID, userID); err != nil {
+			_ = tx.Rollback()
+			return "", err
+		}
+		return contact.StatusAccepted, tx.Commit()
+	}
+	if err = lockRequester(tx, userID); err != nil {
+		_ = tx.Rollback()
+		return "", err
+	}
+	pending := 0
+	err = tx.Get(&pending, "SELECT COUNT(*) FROM shogun.contact WHERE user_id = $1 AND status = $2", userID, contact.StatusPending)
+	if err != nil {
+		_ = tx.Rollback()
+		return "", err
+	}
+	if pending >= MaxPendingOutgoing {
+		_ = tx.Rollback()
+		return "", ErrorTooManyPending
+	}
+	_, err = tx.Exec("INSERT INTO shogun.contact(user_id, contact_id, status) VALUES ($1, $2, $3)", userID, contactID, contact.StatusPending)
+	if err != nil {
+		_ = tx.Rollback()
+		return "", err
+	}
+	return contact.StatusPending, tx.Commit()
+}
+
+func (s *SqlStore) Accept(userID, requesterID int64) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	if err = lockPair(tx, userID, requesterID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err = acceptNoCommit(tx, requesterID, userID); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SqlStore) Decline(userID, requesterID int64) error {
+	res, err := s.db.Exec("DELETE FROM shogun.contact WHERE user_id = $1 AND contact_id = $2 AND status = $3",
+		requesterID, userID, contact.StatusPending)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrorRequestNotFound
+	}
+	return nil
+}
+
+func (s *SqlStore) Remove(userID, otherID int64) error {
+	res, err := s.db.Exec(`DELETE FROM shogun.contact
+		WHERE (user_id = $1 AND contact_id = $2) OR (user_id = $2 AND contact_id = $1)`, userID, otherID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrorNotContacts
+	}
+	return nil
+}
+
+func (s *SqlStore) List(userID, before int64, limit int) ([]contact.Contact, error) {
+	res := make([]contact.Contact, 0)
+	err := s.db.Select(&res, `SELECT * FROM shogun.contact
+		WHERE user_id = $1 AND status = $2 AND ($3 = 0 OR id < $3)
+		ORDER BY id DESC LIMIT $4`, userID, contact.StatusAccepted, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *SqlStore) Incoming(userID, before int64, limit int) ([]contact.Contact, error) {
+	res := make([]contact.Contact, 0)
+	err := s.db.Select(&res, `SELECT * FROM shogun.contact
+		WHERE contact_id = $1 AND status = $2 AND ($3 = 0 OR id < $3)
+		ORDER BY id DESC LIMIT $4`, userID, contact.StatusPending, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *SqlStore) Outgoing(userID, before int64, limit int) ([]contact.Contact, error) {
+	res := make([]contact.Contact, 0)
+	err := s.db.Select(&res, `SELECT * FROM shogun.contact
+		WHERE user_id = $1 AND status = $2 AND ($3 = 0 OR id < $3)
+		ORDER BY id DESC LIMIT $4`, userID, contact.StatusPending, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *SqlStore) Mutual(userID, otherID, before int64, limit int) ([]contact.Contact, error) {
+	res := make([]contact.Contact, 0)
+	err := s.db.Select(&res, `SELECT a.* FROM shogun.contact a
+		JOIN shogun.contact b ON b.user_id = $2 AND b.contact_id = a.contact_id AND b.status = $3
+		WHERE a.user_id = $1 AND a.status = $3 AND ($4 = 0 OR a.id < $4)
+		ORDER BY a.id DESC LIMIT $5`, userID, otherID, contact.StatusAccepted, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *SqlStore) ContactsAmong(userID int64, otherIDs []int64) (map[int64]bool, error) {
+	res := make(map[int64]bool, len(otherIDs))
+	if len(otherIDs) == 0 {
+		return res, nil
+	}
+	ids := make([]int64, 0)
+	err := s.db.Select(&ids, `SELECT contact_id FROM shogun.contact
+		WHERE user_id = $1 AND status = $2 AND contact_id = ANY($3::BIGINT[])`, userID, contact.StatusAccepted, otherIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		res[id] = true
+	}
+	return res, nil
+}
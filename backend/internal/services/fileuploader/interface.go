@@ -2,4 +2,8 @@ package fileuploader
 
 type Service interface {
 	Upload(params *Data) (string, error)
+	// Delete - removes the files, missing files are not an error
+	Delete(fileNames ...string) error
+	// List - names of all files starting with prefix
+	List(prefix string) ([]string, error)
 }
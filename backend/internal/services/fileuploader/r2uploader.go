@@ -4,15 +4,20 @@ import (
 	"fmt"
 	"io"
 	"shogun/config"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 )
 
 const returnUrlPrefix = "https://images.shogun.social/"
 
+// s3 refuses to delete more than this in one call
+const maxDeleteBatch = 1000
+
 type Uploader struct {
 	endPoint string
 	region   string
@@ -36,12 +41,24 @@ func NewUploaderService() *Uploader {
 	}
 }
 
-func (u *Uploader) Upload(params *Data) (string, error) {
-	sess, err := session.NewSession(&aws.Config{
+// FileName - the file name of a location returned by Upload, empty if it's not one of ours
+func FileName(location string) string {
+	if !strings.HasPrefix(location, returnUrlPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(location, returnUrlPrefix)
+}
+
+func (u *Uploader) newSession() (*session.Session, error) {
+	return session.NewSession(&aws.Config{
 		Region:      aws.String(u.region),
 		Endpoint:    aws.String(u.endPoint),
 		Credentials: credentials.NewStaticCredentials(config.Cfg.R2AccessKeyID, config.Cfg.R2SecretAccessKey, ""),
 	})
+}
+
+func (u *Uploader) Upload(params *Data) (string, error) {
+	sess, err := u.newSession()
 	if err != nil {
 		return "", err
 	}
@@ -57,3 +74,53 @@ func (u *Uploader) Upload(params *Data) (string, error) {
 	}
 	return returnUrlPrefix + params.FileName, nil
 }
+
+func (u *Uploader) Delete(fileNames ...string) error {
+	if len(fileNames) == 0 {
+		return nil
+	}
+	sess, err := u.newSession()
+	if err != nil {
+		return err
+	}
+	client := s3.New(sess)
+	for start := 0; start < len(fileNames); start += maxDeleteBatch {
+		end := min(start+maxDeleteBatch, len(fileNames))
+		objects := make([]*s3.ObjectIdentifier, 0, end-start)
+		for _, name := range fileNames[start:end] {
+			objects = append(objects, &s3.ObjectIdentifier{Key: aws.String(name)})
+		}
+		out, err := client.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(u.bucket),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+		if len(out.Errors) > 0 {
+			return fmt.Errorf("failed to delete %s: %s", aws.StringValue(out.Errors[0].Key), aws.StringValue(out.Errors[0].Message))
+		}
+	}
+	return nil
+}
+
+func (u *Uploader) List(prefix string) ([]string, error) {
+	sess, err := u.newSession()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	err = s3.New(sess).ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(u.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, obj := range page.Contents {
+			names = append(names, aws.StringValue(obj.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
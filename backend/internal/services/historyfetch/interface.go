@@ -5,12 +5,20 @@ import (
 	"errors"
 	"shogun/internal/model/chain"
 	"shogun/internal/model/transaction"
+	"shogun/internal/services/interactionstore"
+	"shogun/internal/services/screening"
+	"shogun/internal/services/usercache"
+	"shogun/internal/services/visibility"
+
+	"github.com/rs/zerolog/log"
 )
 
 var ErrorChainNotSupported = errors.New("chain not supported")
 
 type AllFetcher interface {
-	Fetch(ctx context.Context, address string, chain chain.Chain) ([]transaction.Transaction, error)
+	// Fetch - history of address as viewerID may see it, counterparties hiding their addresses from
+	// the viewer come without their user
+	Fetch(ctx context.Context, viewerID int64, address string, chain chain.Chain) ([]transaction.Transaction, error)
 }
 
 type ChainFetcher interface {
@@ -18,19 +26,95 @@ type ChainFetcher interface {
 }
 
 type AllChainFetcher struct {
-	fetchers map[chain.Chain]ChainFetcher
+	fetchers     map[chain.Chain]ChainFetcher
+	screener     screening.Screener
+	userCache    usercache.SimpleCache
+	interactions interactionstore.Store
+	visibility   *visibility.Policy
 }
 
-func NewAllChainFetcher(fetchers map[chain.Chain]ChainFetcher) *AllChainFetcher {
+func NewAllChainFetcher(
+	fetchers map[chain.Chain]ChainFetcher,
+	screener screening.Screener,
+	userCache usercache.SimpleCache,
+	interactions interactionstore.Store,
+	visibility *visibility.Policy,
+) *AllChainFetcher {
 	return &AllChainFetcher{
-		fetchers: fetchers,
+		fetchers:     fetchers,
+		screener:     screener,
+		userCache:    userCache,
+		interactions: interactions,
+		visibility:   visibility,
 	}
 }
 
-func (a *AllChainFetcher) Fetch(ctx context.Context, address string, chain chain.Chain) ([]transaction.Transaction, error) {
+func (a *AllChainFetcher) Fetch(ctx context.Context, viewerID int64, address string, chain chain.Chain) ([]transaction.Transaction, error) {
 	f, exists := a.fetchers[chain]
 	if !exists {
 		return nil, ErrorChainNotSupported
 	}
-	return f.Fetch(ctx, address)
+	txs, err := f.Fetch(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	a.annotate(txs, address, chain)
+	if err = a.hideUsers(txs, viewerID); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// annotate - chain fetchers only know the chain, anything we know about the counterparties is added here
+func (a *AllChainFetcher) annotate(txs []transaction.Transaction, address string, c chain.Chain) {
+	markSpam(txs, address)
+	for i := range txs {
+		tx := &txs[i]
+		if other := tx.Counterparty(address); other != "" {
+			tx.Risk = a.screener.Screen(other, c)
+		}
+	}
+	a.recordInteractions(txs, address, c)
+}
+
+// hideUsers - drops the counterparties viewerID isn't allowed to tie to their address
+func (a *AllChainFetcher) hideUsers(txs []transaction.Transaction, viewerID int64) error {
+	ids := make([]int64, 0)
+	for i := range txs {
+		if txs[i].User != nil {
+			ids = append(ids, txs[i].User.ID)
+		}
+	}
+	visible, err := a.visibility.Filter(viewerID, ids, visibility.ByAddress)
+	if err != nil {
+		return err
+	}
+	for i := range txs {
+		if txs[i].User != nil && !visible[txs[i].User.ID] {
+			txs[i].User = nil
+		}
+	}
+	return nil
+}
+
+// recordInteractions - remembers the users the owner of address sent to or received from,
+// user search ranks them first, spam and failed transactions don't count
+func (a *AllChainFetcher) recordInteractions(txs []transaction.Transaction, address string, c chain.Chain) {
+	owner, err := a.userCache.GetByAddress(address, c)
+	if err != nil {
+		return
+	}
+	seen := make(map[int64]bool)
+	pairs := make([]interactionstore.Pair, 0)
+	for i := range txs {
+		tx := &txs[i]
+		if tx.User == nil || tx.Failed || tx.IsSpam() || tx.User.ID == owner.ID || seen[tx.User.ID] {
+			continue
+		}
+		seen[tx.User.ID] = true
+		pairs = append(pairs, interactionstore.Pair{UserID: owner.ID, OtherID: tx.User.ID})
+	}
+	if err = a.interactions.Record(pairs); err != nil {
+		log.Err(err).Int64("user_id", owner.ID).Msg("failed to record interactions")
+	}
 }
@@ -0,0 +1,133 @@
+package historyfetch
+
+import (
+	"context"
+	"shogun/internal/model/chain"
+	"shogun/internal/model/preferences"
+	"shogun/internal/model/transaction"
+	"shogun/internal/model/user"
+	"shogun/internal/services/interactionstore"
+	"shogun/internal/services/screening"
+	"shogun/internal/services/usercache"
+	"shogun/internal/services/visibility"
+	"shogun/internal/services/visibility/visibilitytest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUsers struct {
+	usercache.SimpleCache
+	byAddress map[string]*user.Simple
+}
+
+func (f fakeUsers) GetByAddress(address string, _ chain.Chain) (*user.Simple, error) {
+	if u, ok := f.byAddress[address]; ok {
+		return u, nil
+	}
+	return nil, usercache.ErrorUserNotFound
+}
+
+type fakeInteractions struct {
+	recorded []interactionstore.Pair
+}
+
+func (f *fakeInteractions) Record(pairs []interactionstore.Pair) error {
+	f.recorded = append(f.recorded, pairs...)
+	return nil
+}
+
+func TestRecordInteractions(t *testing.T) {
+	owner := &user.Simple{ID: 1}
+	friendUser := &user.Simple{ID: 2}
+	spammer := &user.Simple{ID: 3}
+	interactions := &fakeInteractions{}
+	a := NewAllChainFetcher(nil, nil, fakeUsers{byAddress: map[string]*user.Simple{me: owner}}, interactions, nil)
+
+	withUser := func(tx transaction.Transaction, u *user.Simple) transaction.Transaction {
+		tx.User = u
+		return tx
+	}
+	failed := withUser(transfer(me, friend, usdc, "3"), friendUser)
+	failed.Failed = true
+	spam := withUser(transfer(poisoner, me, usdc, "0"), spammer)
+	spam.Spam = []transaction.SpamReason{transaction.SpamZeroValue}
+	txs := []transaction.Transaction{
+		withUser(transfer(me, friend, usdc, "5"), friendUser),
+		withUser(transfer(friend, me, usdc, "1"), friendUser),
+		transfer(me, stranger, usdc, "2"),
+		failed,
+		spam,
+	}
+
+	a.recordInteractions(txs, me, chain.Solana)
+	assert.Equal(t, []interactionstore.Pair{{UserID: 1, OtherID: 2}}, interactions.recorded)
+
+	//history of an address no user owns has nobody to record for
+	interactions.recorded = nil
+	a.recordInteractions(txs, stranger, chain.Solana)
+	assert.Empty(t, interactions.recorded)
+}
+
+type fakeChainFetcher struct {
+	txs []transaction.Transaction
+}
+
+func (f fakeChainFetcher) Fetch(context.Context, string) ([]transaction.Transaction, error) {
+	//every fetch gets its own copy, like a real fetcher
+	txs := make([]transaction.Transaction, len(f.txs))
+	for i, tx := range f.txs {
+		if tx.User != nil {
+			u := *tx.User
+			tx.User = &u
+		}
+		txs[i] = tx
+	}
+	return txs, nil
+}
+
+func TestFetch_HidesUsersWithAddressSearchOff(t *testing.T) {
+	const (
+		ownerID    = 1
+		hiddenID   = 2
+		publicID   = 3
+		contactID  = 4
+		strangerID = 5
+	)
+	off := false
+	policy := visibility.New(
+		visibilitytest.Prefs{Of: map[int64]preferences.Preferences{hiddenID: {SearchAddress: &off}}},
+		&visibilitytest.Contacts{Of: map[int64][]int64{contactID: {hiddenID}, hiddenID: {contactID}}},
+	)
+	chainFetcher := fakeChainFetcher{txs: []transaction.Transaction{
+		{Type: transaction.TypeTransfer, FromAddress: me, ToAddress: friend, User: &user.Simple{ID: hiddenID, Username: "hidden"}},
+		{Type: transaction.TypeTransfer, FromAddress: stranger, ToAddress: me, User: &user.Simple{ID: publicID, Username: "public"}},
+	}}
+	a := NewAllChainFetcher(
+		map[chain.Chain]ChainFetcher{chain.Solana: chainFetcher},
+		screening.None{},
+		fakeUsers{byAddress: map[string]*user.Simple{me: {ID: ownerID}}},
+		&fakeInteractions{},
+		policy)
+
+	fetch := func(viewerID int64) []transaction.Transaction {
+		txs, err := a.Fetch(context.Background(), viewerID, me, chain.Solana)
+		assert.NoError(t, err)
+		assert.Len(t, txs, 2)
+		return txs
+	}
+
+	//the owner of the history, a stranger and nobody logged in never see the hidden user
+	for _, viewerID := range []int64{ownerID, strangerID, 0} {
+		txs := fetch(viewerID)
+		assert.Nil(t, txs[0].User, "viewer %d", viewerID)
+		assert.Equal(t, friend, txs[0].ToAddress)
+		assert.Equal(t, int64(publicID), txs[1].User.ID)
+	}
+
+	//the hidden user sees themselves and so do their contacts
+	for _, viewerID := range []int64{hiddenID, contactID} {
+		txs := fetch(viewerID)
+		assert.Equal(t, int64(hiddenID), txs[0].User.ID, "viewer %d", viewerID)
+	}
+}
@@ -0,0 +1,119 @@
+package historyfetch
+
+import (
+	"shogun/internal/model/transaction"
+	"shogun/internal/services/walletstore"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// lookAlikePrefix, lookAlikeSuffix - what wallets show of a shortened address,
+	// poisoners grind keys until both ends match
+	lookAlikePrefix = 4
+	lookAlikeSuffix = 4
+)
+
+// nativeDust - incoming native amounts below these cost more in fees than they are worth,
+// tokens have no price here so only zero token amounts count as spam
+var nativeDust = map[string]decimal.Decimal{
+	solana.SystemProgramID.String(): decimal.RequireFromString("0.001"),
+	walletstore.SuiCoinAddress:      decimal.RequireFromString("0.01"),
+}
+
+// markSpam - flags zero value and dust transfers the user didn't send, and transfers from
+// addresses that look like one the user really sent to. Only the fetched page is known,
+// so a counterparty the user sent to long ago isn't protected.
+func markSpam(txs []transaction.Transaction, address string) {
+	known := knownCounterparties(txs, address)
+	for i := range txs {
+		tx := &txs[i]
+		if tx.Type != transaction.TypeTransfer {
+			continue
+		}
+		sentByUser := tx.FromAddress == address
+		zero := isZeroValue(tx)
+		if zero {
+			tx.Spam = append(tx.Spam, transaction.SpamZeroValue)
+		} else if !sentByUser && isDust(tx) {
+			tx.Spam = append(tx.Spam, transaction.SpamDust)
+		}
+		//a zero value transfer can show up as sent by the user without them signing it
+		if sentByUser && !zero {
+			continue
+		}
+		other := tx.Counterparty(address)
+		if other == "" {
+			continue
+		}
+		if _, ok := known[normalizeAddress(other)]; ok {
+			continue
+		}
+		for k := range known {
+			if looksAlike(normalizeAddress(other), k) {
+				tx.Spam = append(tx.Spam, transaction.SpamPoisoning)
+				break
+			}
+		}
+	}
+}
+
+// knownCounterparties - addresses the user sent something to, what poisoners imitate
+func knownCounterparties(txs []transaction.Transaction, address string) map[string]struct{} {
+	known := make(map[string]struct{})
+	for _, tx := range txs {
+		if tx.Type != transaction.TypeTransfer || tx.Failed || tx.FromAddress != address || tx.ToAddress == "" {
+			continue
+		}
+		if isZeroValue(&tx) {
+			continue
+		}
+		known[normalizeAddress(tx.ToAddress)] = struct{}{}
+	}
+	return known
+}
+
+// normalizeAddress - sui addresses are hex so case doesn't matter and the 0x says nothing
+func normalizeAddress(address string) string {
+	if strings.HasPrefix(address, "0x") {
+		return strings.ToLower(address[2:])
+	}
+	return address
+}
+
+func looksAlike(a, b string) bool {
+	if a == b || len(a) < lookAlikePrefix+lookAlikeSuffix || len(b) < lookAlikePrefix+lookAlikeSuffix {
+		return false
+	}
+	return a[:lookAlikePrefix] == b[:lookAlikePrefix] && a[len(a)-lookAlikeSuffix:] == b[len(b)-lookAlikeSuffix:]
+}
+
+// isZeroValue - every change moved nothing, no changes means the amounts couldn't be
+// read, like a token lookup that failed, not that nothing moved
+func isZeroValue(tx *transaction.Transaction) bool {
+	if len(tx.Changes) == 0 {
+		return false
+	}
+	for _, ch := range tx.Changes {
+		if !ch.UIAmount.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// isDust - every change is a native amount under the dust line
+func isDust(tx *transaction.Transaction) bool {
+	if len(tx.Changes) == 0 {
+		return false
+	}
+	for _, ch := range tx.Changes {
+		limit, native := nativeDust[ch.Address]
+		if !native || ch.UIAmount.Abs().GreaterThanOrEqual(limit) {
+			return false
+		}
+	}
+	return true
+}
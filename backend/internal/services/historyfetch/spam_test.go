@@ -0,0 +1,61 @@
+package historyfetch
+
+import (
+	"shogun/internal/model/token"
+	"shogun/internal/model/transaction"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	me       = "Me11111111111111111111111111111111111111111"
+	friend   = "FrNdAbCdEfGhJkLmNpQrStUvWxYz123456789aBcD7xQ2"
+	poisoner = "FrNdZzZzZzZzZzZzZzZzZzZzZzZzZzZzZzZzZzZz7xQ2"
+	stranger = "StRaNgEr1111111111111111111111111111111111"
+	usdc     = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+)
+
+func transfer(from, to, mint, amount string) transaction.Transaction {
+	return transaction.Transaction{
+		Type:        transaction.TypeTransfer,
+		FromAddress: from,
+		ToAddress:   to,
+		Changes: []transaction.Transfer{{
+			UIAmount: decimal.RequireFromString(amount),
+			Token:    token.Token{Address: mint},
+		}},
+	}
+}
+
+func TestMarkSpam(t *testing.T) {
+	sol := solana.SystemProgramID.String()
+	txs := []transaction.Transaction{
+		transfer(me, friend, usdc, "-25"),
+		transfer(poisoner, me, sol, "0.0001"),
+		transfer(me, poisoner, usdc, "0"),
+		transfer(stranger, me, sol, "0.00001"),
+		transfer(stranger, me, usdc, "0.00001"),
+		transfer(friend, me, sol, "1.5"),
+		//the amounts couldn't be read
+		{Type: transaction.TypeTransfer, FromAddress: stranger, ToAddress: me},
+	}
+	markSpam(txs, me)
+
+	assert.Empty(t, txs[0].Spam, "a real transfer by the user")
+	assert.Equal(t, []transaction.SpamReason{transaction.SpamDust, transaction.SpamPoisoning}, txs[1].Spam)
+	assert.Equal(t, []transaction.SpamReason{transaction.SpamZeroValue, transaction.SpamPoisoning}, txs[2].Spam)
+	assert.Equal(t, []transaction.SpamReason{transaction.SpamDust}, txs[3].Spam)
+	assert.Empty(t, txs[4].Spam, "tokens have no price so small amounts aren't dust")
+	assert.Empty(t, txs[5].Spam)
+	assert.Empty(t, txs[6].Spam, "an unknown amount isn't zero")
+}
+
+func TestLooksAlike(t *testing.T) {
+	assert.True(t, looksAlike(friend, poisoner))
+	assert.False(t, looksAlike(friend, friend))
+	assert.False(t, looksAlike(friend, stranger))
+	assert.Equal(t, "ab12", normalizeAddress("0xAB12"))
+}
@@ -0,0 +1,12 @@
+package interactionstore
+
+// Pair - UserID and OtherID sent to or received from each other
+type Pair struct {
+	UserID  int64
+	OtherID int64
+}
+
+type Store interface {
+	// Record - remembers the pairs both ways, recording a known pair again does nothing
+	Record(pairs []Pair) error
+}
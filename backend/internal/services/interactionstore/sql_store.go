@@ -0,0 +1,31 @@
+package interactionstore
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+type SqlStore struct {
+	db *sqlx.DB
+}
+
+func NewSqlStore(db *sqlx.DB) *SqlStore {
+	return &SqlStore{
+		db: db,
+	}
+}
+
+func (s *SqlStore) Record(pairs []Pair) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+	users := make([]int64, 0, len(pairs)*2)
+	others := make([]int64, 0, len(pairs)*2)
+	for _, p := range pairs {
+		users = append(users, p.UserID, p.OtherID)
+		others = append(others, p.OtherID, p.UserID)
+	}
+	_, err := s.db.Exec(`INSERT INTO shogun.user_interaction(user_id, other_id)
+		SELECT * FROM unnest($1::BIGINT[], $2::BIGINT[])
+		ON CONFLICT DO NOTHING`, users, others)
+	return err
+}
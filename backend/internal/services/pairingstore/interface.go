@@ -0,0 +1,23 @@
+package pairingstore
+
+import (
+	"context"
+	"errors"
+	"shogun/internal/model/pairing"
+)
+
+var ErrorPairingNotFound = errors.New("pairing not found")
+var ErrorPairingResolved = errors.New("pairing already approved or rejected")
+var ErrorPairingNotApproved = errors.New("pairing not approved")
+
+// Store - pairings shared by every api server, the desktop and the phone can hit different ones
+type Store interface {
+	Create(p *pairing.Pairing) error
+	Get(code string) (*pairing.Pairing, error)
+	// Resolve - approves the pending pairing for the user or rejects it, only the first answer counts
+	Resolve(code string, userID int64, approve bool) (*pairing.Pairing, error)
+	// Claim - marks the approved pairing as used, only one caller ever gets it
+	Claim(code string) (*pairing.Pairing, error)
+	// Wait - blocks until the pairing is resolved or ctx is done and returns its latest state
+	Wait(ctx context.Context, code string) (*pairing.Pairing, error)
+}
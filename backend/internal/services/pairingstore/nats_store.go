@@ -0,0 +1,177 @@
+package pairingstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"shogun/internal/model/pairing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog/log"
+)
+
+const natsPairingBucketName = "device-pairings"
+
+type Nats struct {
+	store jetstream.KeyValue
+}
+
+// NewNats - ttl is how long a pairing code can be used, the bucket drops it after that
+func NewNats(j jetstream.JetStream, ttl time.Duration) *Nats {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	b, err := j.KeyValue(ctx, natsPairingBucketName)
+	if err != nil && !errors.Is(err, jetstream.ErrBucketNotFound) {
+		log.Fatal().Err(err).Msg("failed to get pairings bucket")
+	}
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		b, err = j.CreateKeyValue(ctx,
+			jetstream.KeyValueConfig{
+				Bucket:      natsPairingBucketName,
+				Description: "bucket for desktop qr pairings",
+				History:     1,
+				TTL:         ttl,
+				Storage:     jetstream.FileStorage,
+			})
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create pairings bucket")
+		}
+	}
+	return &Nats{store: b}
+}
+
+func (n *Nats) Create(p *pairing.Pairing) error {
+	jsonData, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = n.store.Create(ctx, p.Code, jsonData)
+	return err
+}
+
+func (n *Nats) Get(code string) (*pairing.Pairing, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	p, _, err := n.get(ctx, code)
+	return p, err
+}
+
+func (n *Nats) get(ctx context.Context, code string) (*pairing.Pairing, uint64, error) {
+	val, err := n.store.Get(ctx, code)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) || errors.Is(err, jetstream.ErrInvalidKey) {
+			return nil, 0, ErrorPairingNotFound
+		}
+		return nil, 0, err
+	}
+	p, err := decode(val.Value())
+	if err != nil {
+		return nil, 0, err
+	}
+	return p, val.Revision(), nil
+}
+
+func decode(data []byte) (*pairing.Pairing, error) {
+	p := &pairing.Pairing{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	if p.IsExpired() {
+		return nil, ErrorPairingNotFound
+	}
+	return p, nil
+}
+
+// transition - moves the pairing from one status to the next, writing at the revision
+// we read so two servers can't both make the same move
+func (n *Nats) transition(code string, from pairing.Status, change func(p *pairing.Pairing), wrongStatus error) (*pairing.Pairing, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	p, revision, err := n.get(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != from {
+		return nil, wrongStatus
+	}
+	change(p)
+	jsonData, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	_, err = n.store.Update(ctx, code, jsonData, revision)
+	if err != nil {
+		var apiErr *jetstream.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode == jetstream.JSErrCodeStreamWrongLastSequence {
+			return nil, wrongStatus
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func (n *Nats) Resolve(code string, userID int64, approve bool) (*pairing.Pairing, error) {
+	return n.transition(code, pairing.StatusPending, func(p *pairing.Pairing) {
+		p.Status = pairing.StatusRejected
+		if approve {
+			p.Status = pairing.StatusApproved
+			p.UserID = userID
+		}
+	}, ErrorPairingResolved)
+}
+
+func (n *Nats) Claim(code string) (*pairing.Pairing, error) {
+	return n.transition(code, pairing.StatusApproved, func(p *pairing.Pairing) {
+		p.Status = pairing.StatusClaimed
+	}, ErrorPairingNotApproved)
+}
+
+func (n *Nats) Wait(ctx context.Context, code string) (*pairing.Pairing, error) {
+	watcher, err := n.store.Watch(ctx, code)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrInvalidKey) {
+			return nil, ErrorPairingNotFound
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = watcher.Stop()
+	}()
+
+	var latest *pairing.Pairing
+	for {
+		select {
+		case <-ctx.Done():
+			if latest == nil {
+				return nil, ErrorPairingNotFound
+			}
+			return latest, nil
+		case entry, ok := <-watcher.Updates():
+			if !ok {
+				if latest == nil {
+					return nil, ErrorPairingNotFound
+				}
+				return latest, nil
+			}
+			//nil marks the end of the current values, a missing key never shows up
+			if entry == nil {
+				if latest == nil {
+					return nil, ErrorPairingNotFound
+				}
+				continue
+			}
+			if entry.Operation() != jetstream.KeyValuePut {
+				return nil, ErrorPairingNotFound
+			}
+			if latest, err = decode(entry.Value()); err != nil {
+				return nil, err
+			}
+			if latest.IsResolved() {
+				return latest, nil
+			}
+		}
+	}
+}
@@ -13,4 +13,7 @@ type Store interface {
 	Create(userID int64, u *preferences.Preferences) error
 	Update(userID int64, preferences *preferences.Preferences) error
 	Get(userID int64) (*preferences.Preferences, error)
+	// GetMany - preferences of the users that have them, missing users are left out of the map
+	GetMany(userIDs []int64) (map[int64]preferences.Preferences, error)
+	Delete(userID int64) error
 }
@@ -72,6 +72,21 @@ func (jp *Nats) Get(userID int64) (*preferences.Preferences, error) {
 	return pref, err
 }
 
+func (jp *Nats) GetMany(userIDs []int64) (map[int64]preferences.Preferences, error) {
+	res := make(map[int64]preferences.Preferences, len(userIDs))
+	for _, userID := range userIDs {
+		pref, _, err := jp.getForUser(userID)
+		if errors.Is(err, ErrorKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		res[userID] = *pref
+	}
+	return res, nil
+}
+
 func (jp *Nats) getForUser(userID int64) (*preferences.Preferences, uint64, error) {
 	id := strconv.FormatInt(userID, 10)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -109,6 +124,17 @@ func (jp *Nats) Update(userID int64, p *preferences.Preferences) error {
 	return err
 }
 
+func (jp *Nats) Delete(userID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	//purge drops the history too, nothing of the user is kept
+	err := jp.store.Purge(ctx, strconv.FormatInt(userID, 10))
+	if err != nil && !errors.Is(err, jetstream.ErrKeyNotFound) {
+		return err
+	}
+	return nil
+}
+
 func mergePreferences(curr, next *preferences.Preferences) *preferences.Preferences {
 	merged := *curr
 	currVal := reflect.ValueOf(curr).Elem()
@@ -55,7 +55,28 @@ func (s *SqlStore) Get(userID int64) (*preferences.Preferences, error) {
 	return p, nil
 }
 
+func (s *SqlStore) GetMany(userIDs []int64) (map[int64]preferences.Preferences, error) {
+	res := make(map[int64]preferences.Preferences, len(userIDs))
+	if len(userIDs) == 0 {
+		return res, nil
+	}
+	var items []PreferencesItem
+	err := s.db.Select(&items, "SELECT user_id, meta FROM shogun.preferences WHERE user_id = ANY($1::BIGINT[])", userIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		res[item.UserID] = item.Meta
+	}
+	return res, nil
+}
+
 func (s *SqlStore) Update(userID int64, p *preferences.Preferences) error {
 	_, err := s.db.Exec("UPDATE shogun.preferences SET meta = meta || $1 WHERE user_id = $2", p, userID)
 	return err
 }
+
+func (s *SqlStore) Delete(userID int64) error {
+	_, err := s.db.Exec("DELETE FROM shogun.preferences WHERE user_id = $1", userID)
+	return err
+}
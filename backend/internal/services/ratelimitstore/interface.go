@@ -0,0 +1,18 @@
+package ratelimitstore
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrorTooMuchContention = errors.New("rate limit counter kept changing")
+
+// LongestWindow - stores with a single TTL keep counters this long, no policy may use a longer window
+const LongestWindow = time.Hour
+
+// Store - shared fixed window counters, every api server counts in the same place
+type Store interface {
+	// Increment adds one to the counter and returns the new count, the counter
+	// is dropped once ttl has passed
+	Increment(key string, ttl time.Duration) (int64, error)
+}
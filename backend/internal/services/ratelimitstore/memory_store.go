@@ -0,0 +1,31 @@
+package ratelimitstore
+
+import (
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// Memory - counters of this process only, for single server runs and tests
+type Memory struct {
+	counters *cache.Cache
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		counters: cache.New(LongestWindow, 5*time.Minute),
+	}
+}
+
+func (m *Memory) Increment(key string, ttl time.Duration) (int64, error) {
+	if err := m.counters.Add(key, int64(1), ttl); err == nil {
+		return 1, nil
+	}
+	n, err := m.counters.IncrementInt64(key, 1)
+	if err != nil {
+		//expired between Add and Increment, start over
+		m.counters.Set(key, int64(1), ttl)
+		return 1, nil
+	}
+	return n, nil
+}
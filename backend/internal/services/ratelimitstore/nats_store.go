@@ -0,0 +1,83 @@
+package ratelimitstore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	natsRateLimitBucketName = "rate-limits"
+	natsMaxRetries          = 5
+)
+
+// Nats - counters in a JetStream KV bucket, increments are compare-and-set on the revision
+type Nats struct {
+	store jetstream.KeyValue
+}
+
+func NewNats(j jetstream.JetStream) *Nats {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	b, err := j.KeyValue(ctx, natsRateLimitBucketName)
+	if err != nil && !errors.Is(err, jetstream.ErrBucketNotFound) {
+		log.Fatal().Err(err).Msg("failed to get rate limits bucket")
+	}
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		b, err = j.CreateKeyValue(ctx,
+			jetstream.KeyValueConfig{
+				Bucket:      natsRateLimitBucketName,
+				Description: "bucket for rate limit counters",
+				History:     1,
+				TTL:         LongestWindow,
+				Storage:     jetstream.MemoryStorage,
+			})
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create rate limits bucket")
+		}
+	}
+	return &Nats{store: b}
+}
+
+// Increment - the bucket TTL is the longest window, callers put the window start
+// in the key so a counter is never reused after its window ends
+func (n *Nats) Increment(key string, _ time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for i := 0; i < natsMaxRetries; i++ {
+		entry, err := n.store.Get(ctx, key)
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			_, err = n.store.Create(ctx, key, []byte("1"))
+			if err == nil {
+				return 1, nil
+			}
+			if errors.Is(err, jetstream.ErrKeyExists) {
+				continue
+			}
+			return 0, err
+		}
+		if err != nil {
+			return 0, err
+		}
+		count, err := strconv.ParseInt(string(entry.Value()), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		count++
+		_, err = n.store.Update(ctx, key, []byte(strconv.FormatInt(count, 10)), entry.Revision())
+		if err == nil {
+			return count, nil
+		}
+		//another server incremented first, read again
+		var apiErr *jetstream.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode == jetstream.JSErrCodeStreamWrongLastSequence {
+			continue
+		}
+		return 0, err
+	}
+	return 0, ErrorTooMuchContention
+}
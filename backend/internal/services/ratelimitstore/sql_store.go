@@ -0,0 +1,51 @@
+package ratelimitstore
+
+import (
+	"time"
+
+	"github.com/dreson4/graceful/v2"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+const sqlCleanupInterval = 5 * time.Minute
+
+// SqlStore - counters in postgres, one upsert per request
+type SqlStore struct {
+	db *sqlx.DB
+}
+
+func NewSqlStore(db *sqlx.DB) *SqlStore {
+	s := &SqlStore{
+		db: db,
+	}
+	go s.cleanup()
+	return s
+}
+
+func (s *SqlStore) Increment(key string, ttl time.Duration) (int64, error) {
+	var count int64
+	err := s.db.Get(&count, `INSERT INTO shogun.rate_limit (key, count, expires_at) VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET count = shogun.rate_limit.count + 1
+		RETURNING count`, key, time.Now().Add(ttl))
+	return count, err
+}
+
+func (s *SqlStore) cleanup() {
+	ticker := time.NewTicker(sqlCleanupInterval)
+	done := make(chan struct{})
+	graceful.OnShutdown(func() {
+		close(done)
+	})
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.db.Exec("DELETE FROM shogun.rate_limit WHERE expires_at <= NOW()"); err != nil {
+				log.Err(err).Msg("failed to clean up rate limit counters")
+			}
+		case <-done:
+			ticker.Stop()
+			return
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package rolestore
+
+import (
+	"shogun/internal/model/role"
+)
+
+type Store interface {
+	// Get - role of the user, role.None when they have none
+	Get(userID int64) (role.Role, error)
+	// Set - grants the role, role.None removes it, the change is logged with the actor
+	Set(userID int64, r role.Role, actorID int64) error
+}
@@ -0,0 +1,64 @@
+package rolestore
+
+import (
+	"database/sql"
+	"errors"
+	"shogun/internal/model/role"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SqlStore struct {
+	db *sqlx.DB
+}
+
+func NewSqlStore(db *sqlx.DB) *SqlStore {
+	return &SqlStore{
+		db: db,
+	}
+}
+
+func (s *SqlStore) Get(userID int64) (role.Role, error) {
+	var r role.Role
+	err := s.db.Get(&r, "SELECT role FROM shogun.user_role WHERE user_id = $1", userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return role.None, nil
+		}
+		return role.None, err
+	}
+	return r, nil
+}
+
+func (s *SqlStore) Set(userID int64, r role.Role, actorID int64) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var previous role.Role
+	err = tx.Get(&previous, "SELECT role FROM shogun.user_role WHERE user_id = $1 FOR UPDATE", userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if previous == r {
+		return nil
+	}
+	if r == role.None {
+		_, err = tx.Exec("DELETE FROM shogun.user_role WHERE user_id = $1", userID)
+	} else {
+		_, err = tx.Exec(`INSERT INTO shogun.user_role (user_id, role, granted_by) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id) DO UPDATE SET role = EXCLUDED.role, granted_by = EXCLUDED.granted_by, created_at = NOW()`, userID, r, actorID)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO shogun.user_role_log (user_id, role, previous_role, actor_id) VALUES ($1, $2, $3, $4)", userID, r, previous, actorID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,171 @@
+package screening
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"shogun/internal/model/chain"
+	"shogun/internal/model/risk"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dreson4/graceful/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// anyChain - list entries for addresses that look the same on every chain
+const anyChain = "*"
+
+// FileLists - risk lists from csv files in a directory, one list per file named after it.
+// Every line is "chain,address,category,label", chain can be * and lines starting with # are skipped.
+// The directory is checked for changes on an interval and reloaded as a whole, a broken file keeps the last good lists.
+type FileLists struct {
+	dir     string
+	entries atomic.Pointer[map[string][]risk.Flag]
+	stamp   string
+}
+
+func NewFileLists(dir string) (*FileLists, error) {
+	f := &FileLists{dir: dir}
+	empty := make(map[string][]risk.Flag)
+	f.entries.Store(&empty)
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Start - reloads the lists whenever a file is added, removed or changed
+func (f *FileLists) Start(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	graceful.OnShutdown(func() {
+		close(done)
+	})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := f.reload(); err != nil {
+					log.Err(err).Str("dir", f.dir).Msg("failed to reload risk lists, keeping the previous ones")
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (f *FileLists) Screen(address string, c chain.Chain) []risk.Flag {
+	entries := *f.entries.Load()
+	flags := entries[entryKey(string(c), address)]
+	if wildcard := entries[entryKey(anyChain, address)]; len(wildcard) > 0 {
+		flags = append(append([]risk.Flag{}, flags...), wildcard...)
+	}
+	return flags
+}
+
+// entryKey - sui addresses are hex so case doesn't matter, solana's base58 is case sensitive
+func entryKey(c, address string) string {
+	address = strings.TrimSpace(address)
+	if c != string(chain.Solana) {
+		address = strings.ToLower(address)
+	}
+	return c + ":" + address
+}
+
+func (f *FileLists) listFiles() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(f.dir, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// dirStamp - names, sizes and modification times of the list files, changes when any of them does
+func dirStamp(files []string) (string, error) {
+	var b strings.Builder
+	for _, name := range files {
+		info, err := os.Stat(name)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(fmt.Sprintf("%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano()))
+	}
+	return b.String(), nil
+}
+
+func (f *FileLists) reload() error {
+	files, err := f.listFiles()
+	if err != nil {
+		return err
+	}
+	stamp, err := dirStamp(files)
+	if err != nil {
+		return err
+	}
+	if stamp == f.stamp {
+		return nil
+	}
+	entries := make(map[string][]risk.Flag)
+	for _, name := range files {
+		if err = loadFile(name, entries); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(name), err)
+		}
+	}
+	f.entries.Store(&entries)
+	f.stamp = stamp
+	log.Info().Int("lists", len(files)).Int("addresses", len(entries)).Msg("loaded risk lists")
+	return nil
+}
+
+func loadFile(name string, entries map[string][]risk.Flag) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	source := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	return parseList(file, source, entries)
+}
+
+func parseList(r io.Reader, source string, entries map[string][]risk.Flag) error {
+	reader := csv.NewReader(bufio.NewReader(r))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(record) < 3 {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("line %d: want chain,address,category[,label]", line)
+		}
+		c, address, category := strings.TrimSpace(record[0]), record[1], risk.Category(strings.TrimSpace(record[2]))
+		if c != anyChain && !chain.Chain(c).IsSupported() {
+			//lists can cover chains we don't support yet
+			continue
+		}
+		if !category.IsValid() {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("line %d: unknown category %q", line, category)
+		}
+		flag := risk.Flag{Category: category, Source: source}
+		if len(record) > 3 {
+			flag.Label = strings.TrimSpace(record[3])
+		}
+		key := entryKey(c, address)
+		entries[key] = append(entries[key], flag)
+	}
+}
@@ -0,0 +1,46 @@
+package screening
+
+import (
+	"os"
+	"path/filepath"
+	"shogun/internal/model/chain"
+	"shogun/internal/model/risk"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const suiAddress = "0x2a3b000000000000000000000000000000000000000000000000000000000001"
+
+func TestFileLists(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("ofac.csv", "# chain,address,category,label\nsui,"+suiAddress+",sanctioned,SDN entry\n")
+	write("community.csv", "solana,ScamWa11et1111111111111111111111111111111,scam,fake airdrop\nethereum,0xabc,scam\n")
+
+	f, err := NewFileLists(dir)
+	assert.Nil(t, err)
+
+	flags := f.Screen("0x2A3B000000000000000000000000000000000000000000000000000000000001", chain.Sui)
+	assert.Equal(t, []risk.Flag{{Category: risk.CategorySanctioned, Source: "ofac", Label: "SDN entry"}}, flags)
+	assert.Equal(t, risk.LevelBlock, risk.LevelOf(flags))
+
+	assert.Len(t, f.Screen("ScamWa11et1111111111111111111111111111111", chain.Solana), 1)
+	//base58 is case sensitive
+	assert.Empty(t, f.Screen("scamwa11et1111111111111111111111111111111", chain.Solana))
+	assert.Empty(t, f.Screen(suiAddress, chain.Solana))
+
+	//a changed file replaces its entries, a broken one keeps the last good lists
+	write("community.csv", "*,"+suiAddress+",phishing\n")
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, f.reload())
+	assert.Empty(t, f.Screen("ScamWa11et1111111111111111111111111111111", chain.Solana))
+	assert.Len(t, f.Screen(suiAddress, chain.Sui), 2)
+
+	write("broken.csv", "sui,"+suiAddress+",unknown\n")
+	assert.NotNil(t, f.reload())
+	assert.Len(t, f.Screen(suiAddress, chain.Sui), 2)
+}
@@ -0,0 +1,18 @@
+package screening
+
+import (
+	"shogun/internal/model/chain"
+	"shogun/internal/model/risk"
+)
+
+type Screener interface {
+	// Screen - the flags of the address on every loaded list, nil when it's on none
+	Screen(address string, c chain.Chain) []risk.Flag
+}
+
+// None - used when no lists are configured, nothing is ever flagged
+type None struct{}
+
+func (None) Screen(string, chain.Chain) []risk.Flag {
+	return nil
+}
@@ -0,0 +1,6 @@
+package sessionrevoke
+
+type Checker interface {
+	IsRevoked(sessionID int64) bool
+	Revoke(sessionIDs ...int64) error
+}
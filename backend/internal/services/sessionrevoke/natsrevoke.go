@@ -0,0 +1,79 @@
+package sessionrevoke
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dreson4/graceful/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/patrickmn/go-cache"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	revokedSubject = "server.sessions.revoked"
+)
+
+// Handler - keeps the ids of revoked sessions so that their access tokens
+// are rejected before they expire, every revocation is shared right away to
+// other api servers the same way siglocker shares used signatures.
+// A revoked session only needs to be remembered for as long as an
+// access token issued for it can still be valid.
+type Handler struct {
+	natsClient      *nats.Conn
+	isListening     bool
+	RevokedSessions *cache.Cache
+	ttl             time.Duration
+	mu              sync.Mutex
+}
+
+// NewHandler - ttl should be the lifetime of an access token, recentlyRevoked
+// are sessions revoked before this server started that may still have live tokens
+func NewHandler(client *nats.Conn, ttl time.Duration, recentlyRevoked []int64) *Handler {
+	h := &Handler{
+		natsClient:      client,
+		RevokedSessions: cache.New(ttl, 1*time.Minute),
+		ttl:             ttl,
+	}
+	for _, id := range recentlyRevoked {
+		h.RevokedSessions.Set(strconv.FormatInt(id, 10), struct{}{}, ttl)
+	}
+	h.listen()
+	return h
+}
+
+func (h *Handler) listen() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.isListening {
+		return
+	}
+	h.isListening = true
+
+	sub, err := h.natsClient.Subscribe(revokedSubject, func(msg *nats.Msg) {
+		h.RevokedSessions.Set(string(msg.Data), struct{}{}, h.ttl)
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to listen for revoked sessions on nats")
+	}
+	graceful.OnShutdown(func() {
+		_ = sub.Unsubscribe()
+	})
+}
+
+func (h *Handler) Revoke(sessionIDs ...int64) error {
+	for _, id := range sessionIDs {
+		key := strconv.FormatInt(id, 10)
+		h.RevokedSessions.Set(key, struct{}{}, h.ttl)
+		if err := h.natsClient.Publish(revokedSubject, []byte(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Handler) IsRevoked(sessionID int64) bool {
+	_, found := h.RevokedSessions.Get(strconv.FormatInt(sessionID, 10))
+	return found
+}
@@ -0,0 +1,36 @@
+package sessionstore
+
+import (
+	"errors"
+	"shogun/internal/model/session"
+	"time"
+)
+
+var (
+	ErrorSessionNotFound     = errors.New("session not found")
+	ErrorRefreshTokenReused  = errors.New("refresh token reused")
+	ErrorRefreshTokenExpired = errors.New("refresh token expired")
+)
+
+type Store interface {
+	Create(s *session.Session) error
+	Get(id int64) (*session.Session, error)
+	// Rotate swaps the refresh token hash of an active session for a new one,
+	// presenting an already rotated token revokes the whole session
+	Rotate(refreshTokenHash, newRefreshTokenHash string, expiresAt time.Time) (*session.Session, error)
+	Revoke(id int64) error
+	// RevokeForUser revokes one active session, only if it belongs to the user
+	RevokeForUser(userID, id int64) error
+	RevokeAllForUser(userID int64) ([]int64, error)
+	// RevokeOthersForUser revokes every active session of the user except keepID
+	RevokeOthersForUser(userID, keepID int64) ([]int64, error)
+	GetRevokedSince(since time.Time) ([]int64, error)
+	GetActiveForUser(userID int64) ([]session.Session, error)
+	// UpdateLastSeen writes many last seen times in one statement
+	UpdateLastSeen(seen map[int64]time.Time) error
+}
+
+// Toucher - marks a session as seen, must be cheap, it runs on every authenticated request
+type Toucher interface {
+	Touch(sessionID int64)
+}
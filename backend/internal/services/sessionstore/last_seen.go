@@ -0,0 +1,67 @@
+package sessionstore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dreson4/graceful/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// LastSeen - collects session activity in memory and writes it in batches,
+// a session is written at most once per flush interval no matter how many requests it makes
+type LastSeen struct {
+	store    Store
+	interval time.Duration
+	pending  map[int64]time.Time
+	mu       sync.Mutex
+}
+
+func NewLastSeen(store Store, interval time.Duration) *LastSeen {
+	ls := &LastSeen{
+		store:    store,
+		interval: interval,
+		pending:  make(map[int64]time.Time),
+	}
+	go ls.run()
+	return ls
+}
+
+func (ls *LastSeen) Touch(sessionID int64) {
+	ls.mu.Lock()
+	ls.pending[sessionID] = time.Now()
+	ls.mu.Unlock()
+}
+
+func (ls *LastSeen) run() {
+	ticker := time.NewTicker(ls.interval)
+	done := make(chan struct{})
+	graceful.OnShutdown(func() {
+		close(done)
+		ls.flush()
+	})
+	for {
+		select {
+		case <-ticker.C:
+			ls.flush()
+		case <-done:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+func (ls *LastSeen) flush() {
+	ls.mu.Lock()
+	if len(ls.pending) == 0 {
+		ls.mu.Unlock()
+		return
+	}
+	batch := ls.pending
+	ls.pending = make(map[int64]time.Time, len(batch))
+	ls.mu.Unlock()
+
+	if err := ls.store.UpdateLastSeen(batch); err != nil {
+		log.Err(err).Int("sessions", len(batch)).Msg("failed to update sessions last seen")
+	}
+}
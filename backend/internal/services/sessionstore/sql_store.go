@@ -0,0 +1,167 @@
+package sessionstore
+
+import (
+	"database/sql"
+	"errors"
+	"shogun/internal/model/session"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SqlStore struct {
+	db *sqlx.DB
+}
+
+func NewSqlStore(db *sqlx.DB) *SqlStore {
+	return &SqlStore{
+		db: db,
+	}
+}
+
+func (s *SqlStore) Create(ses *session.Session) error {
+	if ses.UserID == 0 {
+		return errors.New("user id missing")
+	}
+	if ses.RefreshTokenHash == "" {
+		return errors.New("refresh token hash missing")
+	}
+	ses.Device.Truncate()
+	ses.CreatedAt = time.Now()
+	ses.UpdatedAt = ses.CreatedAt
+	ses.LastSeenAt = ses.CreatedAt
+	rows, err := s.db.NamedQuery(`INSERT INTO shogun.session (user_id, refresh_token_hash, expires_at, device_name, platform, ip, user_agent, last_seen_at, created_at, updated_at)
+		VALUES (:user_id, :refresh_token_hash, :expires_at, :device_name, :platform, :ip, :user_agent, :last_seen_at, :created_at, :updated_at) RETURNING id`, ses)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		err = rows.Scan(&ses.ID)
+	}
+	return err
+}
+
+func (s *SqlStore) Get(id int64) (*session.Session, error) {
+	ses := session.New()
+	err := s.db.Get(ses, "SELECT * FROM shogun.session WHERE id = $1", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorSessionNotFound
+		}
+		return nil, err
+	}
+	return ses, nil
+}
+
+func (s *SqlStore) Rotate(refreshTokenHash, newRefreshTokenHash string, expiresAt time.Time) (*session.Session, error) {
+	ses := session.New()
+	err := s.db.Get(ses, `UPDATE shogun.session
+		SET previous_token_hash = refresh_token_hash, refresh_token_hash = $2, expires_at = $3, last_seen_at = NOW(), updated_at = NOW()
+		WHERE refresh_token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING *`, refreshTokenHash, newRefreshTokenHash, expiresAt)
+	if err == nil {
+		return ses, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	//the token was not rotated, find out why so the caller can react properly
+	var id int64
+	err = s.db.Get(&id, "SELECT id FROM shogun.session WHERE previous_token_hash = $1 AND revoked_at IS NULL", refreshTokenHash)
+	if err == nil {
+		//an old refresh token came back, someone else holds a copy of it, kill the session
+		if err = s.Revoke(id); err != nil {
+			return nil, err
+		}
+		return &session.Session{ID: id}, ErrorRefreshTokenReused
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	var expired bool
+	err = s.db.Get(&expired, "SELECT expires_at <= NOW() FROM shogun.session WHERE refresh_token_hash = $1 AND revoked_at IS NULL", refreshTokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorSessionNotFound
+		}
+		return nil, err
+	}
+	if expired {
+		return nil, ErrorRefreshTokenExpired
+	}
+	return nil, ErrorSessionNotFound
+}
+
+func (s *SqlStore) Revoke(id int64) error {
+	_, err := s.db.Exec("UPDATE shogun.session SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL", id)
+	return err
+}
+
+func (s *SqlStore) RevokeForUser(userID, id int64) error {
+	res, err := s.db.Exec("UPDATE shogun.session SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()", id, userID)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrorSessionNotFound
+	}
+	return nil
+}
+
+func (s *SqlStore) RevokeOthersForUser(userID, keepID int64) ([]int64, error) {
+	ids := make([]int64, 0)
+	err := s.db.Select(&ids, "UPDATE shogun.session SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL RETURNING id", userID, keepID)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *SqlStore) RevokeAllForUser(userID int64) ([]int64, error) {
+	ids := make([]int64, 0)
+	err := s.db.Select(&ids, "UPDATE shogun.session SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL RETURNING id", userID)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *SqlStore) GetRevokedSince(since time.Time) ([]int64, error) {
+	ids := make([]int64, 0)
+	err := s.db.Select(&ids, "SELECT id FROM shogun.session WHERE revoked_at >= $1", since)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *SqlStore) GetActiveForUser(userID int64) ([]session.Session, error) {
+	sessions := make([]session.Session, 0)
+	err := s.db.Select(&sessions, "SELECT * FROM shogun.session WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW() ORDER BY last_seen_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (s *SqlStore) UpdateLastSeen(seen map[int64]time.Time) error {
+	if len(seen) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(seen))
+	times := make([]time.Time, 0, len(seen))
+	for id, t := range seen {
+		ids = append(ids, id)
+		times = append(times, t)
+	}
+	_, err := s.db.Exec(`UPDATE shogun.session AS s SET last_seen_at = v.seen
+		FROM unnest($1::BIGINT[], $2::TIMESTAMPTZ[]) AS v(id, seen)
+		WHERE s.id = v.id AND s.last_seen_at < v.seen`, ids, times)
+	return err
+}
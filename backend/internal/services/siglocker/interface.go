@@ -1,6 +1,21 @@
 package siglocker
 
+import (
+	"encoding/hex"
+	"errors"
+	"shogun/internal/utils/hashing"
+)
+
+var ErrorSignatureUsed = errors.New("signature already used")
+
+// UseChecker - remembers used signatures to prevent replay attacks,
+// Use checks and marks in one atomic step so two servers can't both accept the same signature
 type UseChecker interface {
-	IsSignatureUsed(signature string) bool
-	Notify(signature string) error
+	// Use marks the signature as used, returns ErrorSignatureUsed if it was used before
+	Use(signature string) error
+}
+
+// signatureKey - signatures vary in length and charset per chain, stores keep the hash
+func signatureKey(signature string) string {
+	return hex.EncodeToString(hashing.Sha256(signature))
 }
@@ -0,0 +1,26 @@
+package siglocker
+
+import (
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// Memory - keeps used signatures in process, only safe when a single api server runs
+type Memory struct {
+	used *cache.Cache
+}
+
+func NewMemory(ttl time.Duration) *Memory {
+	return &Memory{
+		used: cache.New(ttl, 1*time.Minute),
+	}
+}
+
+func (m *Memory) Use(signature string) error {
+	//Add fails when the key exists, which makes it our check-and-mark
+	if err := m.used.Add(signatureKey(signature), struct{}{}, cache.DefaultExpiration); err != nil {
+		return ErrorSignatureUsed
+	}
+	return nil
+}
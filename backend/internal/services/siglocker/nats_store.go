@@ -0,0 +1,55 @@
+package siglocker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog/log"
+)
+
+const natsSignatureBucketName = "used-signatures"
+
+// Nats - used signatures in a JetStream KV bucket, the bucket TTL drops them once
+// they can't be replayed anyway, survives restarts and is shared by all servers
+type Nats struct {
+	store jetstream.KeyValue
+}
+
+func NewNats(j jetstream.JetStream, ttl time.Duration) *Nats {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	b, err := j.KeyValue(ctx, natsSignatureBucketName)
+	if err != nil && !errors.Is(err, jetstream.ErrBucketNotFound) {
+		log.Fatal().Err(err).Msg("failed to get used signatures bucket")
+	}
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		b, err = j.CreateKeyValue(ctx,
+			jetstream.KeyValueConfig{
+				Bucket:      natsSignatureBucketName,
+				Description: "bucket for used signatures, prevents replays",
+				History:     1,
+				TTL:         ttl,
+				Storage:     jetstream.FileStorage,
+			})
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create used signatures bucket")
+		}
+	}
+	return &Nats{store: b}
+}
+
+func (n *Nats) Use(signature string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	//Create only succeeds if the key doesn't exist, the server decides so it's atomic across servers
+	_, err := n.store.Create(ctx, signatureKey(signature), []byte{1})
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return ErrorSignatureUsed
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package siglocker
+
+import (
+	"time"
+
+	"github.com/dreson4/graceful/v2"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+const sqlCleanupInterval = 10 * time.Minute
+
+// SqlStore - used signatures in postgres, the primary key makes marking atomic
+type SqlStore struct {
+	db  *sqlx.DB
+	ttl time.Duration
+}
+
+func NewSqlStore(db *sqlx.DB, ttl time.Duration) *SqlStore {
+	s := &SqlStore{
+		db:  db,
+		ttl: ttl,
+	}
+	go s.cleanup()
+	return s
+}
+
+func (s *SqlStore) Use(signature string) error {
+	//an expired row can be taken over, otherwise the conflict leaves it alone and nothing is returned
+	res, err := s.db.Exec(`INSERT INTO shogun.used_signature (hash, expires_at) VALUES ($1, $2)
+		ON CONFLICT (hash) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		WHERE shogun.used_signature.expires_at <= NOW()`, signatureKey(signature), time.Now().Add(s.ttl))
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrorSignatureUsed
+	}
+	return nil
+}
+
+func (s *SqlStore) cleanup() {
+	ticker := time.NewTicker(sqlCleanupInterval)
+	done := make(chan struct{})
+	graceful.OnShutdown(func() {
+		close(done)
+	})
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.db.Exec("DELETE FROM shogun.used_signature WHERE expires_at <= NOW()"); err != nil {
+				log.Err(err).Msg("failed to clean up used signatures")
+			}
+		case <-done:
+			ticker.Stop()
+			return
+		}
+	}
+}
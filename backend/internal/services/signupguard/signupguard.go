@@ -0,0 +1,100 @@
+package signupguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"shogun/internal/security/proofofwork"
+	"shogun/internal/services/ratelimitstore"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var ErrorThrottled = errors.New("too many sign ups from this network")
+var ErrorProofRequired = errors.New("proof of work missing or invalid")
+
+// window - sign ups are counted per hour, the longest window the shared counters keep
+const window = ratelimitstore.LongestWindow
+
+// keyReplacer - ipv6 colons and the dots of ipv4 are not allowed inside a nats kv key token
+var keyReplacer = strings.NewReplacer(":", "_", ".", "_", "/", "-")
+
+// Attempt - a fresh keypair logging in for the first time
+type Attempt struct {
+	IP    string
+	Nonce string
+	Proof string
+}
+
+// Guard - limits how many users a network can create, only the new user path goes through it
+// so existing users logging in are never slowed down
+type Guard struct {
+	counters   ratelimitstore.Store
+	perIP      int64
+	perSubnet  int64
+	difficulty int
+}
+
+// New - zero limits turn that check off, difficulty 0 turns proof of work off
+func New(counters ratelimitstore.Store, perIP, perSubnet int64, difficulty int) *Guard {
+	return &Guard{
+		counters:   counters,
+		perIP:      perIP,
+		perSubnet:  perSubnet,
+		difficulty: min(difficulty, proofofwork.MaxDifficulty),
+	}
+}
+
+// Difficulty - leading zero bits the proof over the login nonce needs
+func (g *Guard) Difficulty() int {
+	return g.difficulty
+}
+
+func (g *Guard) Limits() (perIP, perSubnet int64) {
+	return g.perIP, g.perSubnet
+}
+
+// Check - proof first since it costs us nothing, then counts the attempt against the ip and its subnet
+func (g *Guard) Check(a Attempt) error {
+	if !proofofwork.Verify(a.Nonce, a.Proof, g.difficulty) {
+		return ErrorProofRequired
+	}
+	now := time.Now().Unix()
+	windowStart := now - now%int64(window/time.Second)
+	if g.over("ip", a.IP, g.perIP, windowStart) {
+		return ErrorThrottled
+	}
+	if g.over("net", Subnet(a.IP), g.perSubnet, windowStart) {
+		return ErrorThrottled
+	}
+	return nil
+}
+
+func (g *Guard) over(kind, value string, limit, windowStart int64) bool {
+	if limit <= 0 || value == "" {
+		return false
+	}
+	key := fmt.Sprintf("signup.%s.%s.%d", kind, keyReplacer.Replace(value), windowStart)
+	count, err := g.counters.Increment(key, window)
+	if err != nil {
+		//same as the rate limiter, a broken counter store shouldn't stop sign ups
+		log.Err(err).Str("key", key).Msg("sign up throttle check failed")
+		return false
+	}
+	return count > limit
+}
+
+// Subnet - the /24 of an ipv4 or the /48 of an ipv6 address, what one home or
+// small provider usually hands out, empty for anything that doesn't parse
+func Subnet(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
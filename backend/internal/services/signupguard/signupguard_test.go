@@ -0,0 +1,31 @@
+package signupguard
+
+import (
+	"shogun/internal/services/ratelimitstore"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubnet(t *testing.T) {
+	assert.Equal(t, "10.1.2.0/24", Subnet("10.1.2.3"))
+	assert.Equal(t, "2001:db8:1::/48", Subnet("2001:db8:1:2::5"))
+	assert.Equal(t, "", Subnet("not-an-ip"))
+}
+
+func TestCheck(t *testing.T) {
+	g := New(ratelimitstore.NewMemory(), 2, 3, 0)
+	assert.Nil(t, g.Check(Attempt{IP: "10.0.0.1"}))
+	assert.Nil(t, g.Check(Attempt{IP: "10.0.0.1"}))
+	assert.ErrorIs(t, g.Check(Attempt{IP: "10.0.0.1"}), ErrorThrottled)
+
+	//neighbours have their own ip budget but share the subnet one
+	assert.Nil(t, g.Check(Attempt{IP: "10.0.0.2"}))
+	assert.ErrorIs(t, g.Check(Attempt{IP: "10.0.0.3"}), ErrorThrottled)
+	assert.Nil(t, g.Check(Attempt{IP: "10.0.1.1"}))
+}
+
+func TestProofRequired(t *testing.T) {
+	g := New(ratelimitstore.NewMemory(), 0, 0, 8)
+	assert.ErrorIs(t, g.Check(Attempt{IP: "10.0.0.1", Nonce: "n"}), ErrorProofRequired)
+}
@@ -0,0 +1,27 @@
+package signverifier
+
+import (
+	"encoding/hex"
+	"shogun/internal/model/chain"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// IsValidAddress - checks the address is well formed for the chain,
+// sui addresses must be in their full lowercase 0x form
+func IsValidAddress(c chain.Chain, address string) bool {
+	switch c {
+	case chain.Solana:
+		_, err := solana.PublicKeyFromBase58(address)
+		return err == nil
+	case chain.Sui:
+		if !strings.HasPrefix(address, "0x") || len(address) != 66 || strings.ToLower(address) != address {
+			return false
+		}
+		_, err := hex.DecodeString(address[2:])
+		return err == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,93 @@
+package signverifier
+
+import (
+	"errors"
+	"fmt"
+	"shogun/internal/model/account"
+	"shogun/internal/model/attestation"
+	"shogun/internal/model/chain"
+	"shogun/internal/security/accesstoken"
+)
+
+var (
+	ErrorAttestationVersion = errors.New("unknown attestation version")
+	ErrorAttestationMessage = errors.New("attestation message doesn't match the signed message format")
+	ErrorAttestationSigner  = errors.New("attestation signer is not part of the document")
+	ErrorAttestationInvalid = errors.New("invalid attestation signature")
+	ErrorAttestationServer  = errors.New("attestation isn't signed by a published server key")
+)
+
+// VerifyAttestation - checks every statement in the document and the server signature with keys,
+// the published JWKS, then returns the accounts the wallet signatures prove
+// an account is proven when it signed the proof message and the account that linked it is in the
+// document and signed the link message, the linking account is proven by the same signatures
+// entries without statements prove nothing on their own, they aren't an error
+func VerifyAttestation(doc *attestation.Document, keys accesstoken.JWKS) ([]attestation.Signer, error) {
+	if doc.Version != attestation.Version {
+		return nil, ErrorAttestationVersion
+	}
+	proven := make([]attestation.Signer, 0, len(doc.Accounts))
+	seen := make(map[attestation.Signer]bool, len(doc.Accounts))
+	prove := func(s attestation.Signer) {
+		if !seen[s] {
+			seen[s] = true
+			proven = append(proven, s)
+		}
+	}
+	for _, entry := range doc.Accounts {
+		if entry.Proof == nil && entry.Link == nil {
+			continue
+		}
+		if entry.LinkedBy == nil || entry.Proof == nil || entry.Link == nil {
+			return nil, ErrorAttestationMessage
+		}
+		if err := verifyEntry(doc, &entry); err != nil {
+			return nil, err
+		}
+		prove(attestation.Signer{Address: entry.Address, Chain: entry.Chain})
+		prove(*entry.LinkedBy)
+	}
+	//only the server signature ties the accounts to the user id and username
+	canonical, err := doc.Canonical()
+	if err != nil {
+		return nil, err
+	}
+	if err = accesstoken.VerifyDetached(doc.ServerSignature, canonical, keys); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrorAttestationServer, err)
+	}
+	return proven, nil
+}
+
+// VerifyAttestationFor - true when the document checks out and lists the address, so the user named
+// in it controls the address, for accounts without statements that rests on the server signature alone
+func VerifyAttestationFor(doc *attestation.Document, keys accesstoken.JWKS, address string, c chain.Chain) bool {
+	if _, err := VerifyAttestation(doc, keys); err != nil {
+		return false
+	}
+	return doc.Find(address, c) != nil
+}
+
+func verifyEntry(doc *attestation.Document, entry *attestation.Entry) error {
+	self := attestation.Signer{Address: entry.Address, Chain: entry.Chain}
+	linkedBy := *entry.LinkedBy
+	//messages are rebuilt instead of trusted, a document can't make us check a different message
+	if entry.LinkNonce == "" {
+		return ErrorAttestationMessage
+	}
+	if entry.Proof.Signer != self || entry.Proof.Message != account.ProofMessage(linkedBy.Address, entry.LinkNonce) {
+		return ErrorAttestationMessage
+	}
+	if entry.Link.Signer != linkedBy || entry.Link.Message != account.LinkMessage(entry.Address, entry.LinkNonce) {
+		return ErrorAttestationMessage
+	}
+	if doc.Find(linkedBy.Address, linkedBy.Chain) == nil {
+		return ErrorAttestationSigner
+	}
+	if !Verify(self.Chain, entry.Proof.Message, self.Address, entry.Proof.Signature) {
+		return fmt.Errorf("%w: proof of %s", ErrorAttestationInvalid, self.Address)
+	}
+	if !Verify(linkedBy.Chain, entry.Link.Message, linkedBy.Address, entry.Link.Signature) {
+		return fmt.Errorf("%w: link of %s", ErrorAttestationInvalid, self.Address)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package signverifier
+
+import (
+	"shogun/internal/model/account"
+	"shogun/internal/model/attestation"
+	"shogun/internal/model/chain"
+	"shogun/internal/security/accesstoken"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type solanaKey struct {
+	priv    solana.PrivateKey
+	address string
+}
+
+func newSolanaKey(t *testing.T) solanaKey {
+	priv, err := solana.NewRandomPrivateKey()
+	assert.NoError(t, err)
+	return solanaKey{priv: priv, address: priv.PublicKey().String()}
+}
+
+func (k solanaKey) sign(t *testing.T, message string) string {
+	sig, err := k.priv.Sign([]byte(message))
+	assert.NoError(t, err)
+	return sig.String()
+}
+
+// linkedAccount - the account row LinkAccounts stores when primary links linked
+func linkedAccount(t *testing.T, primary, linked solanaKey) account.Account {
+	nonce := linked.address[:8]
+	return account.Account{
+		Address:        linked.address,
+		Chain:          chain.Solana,
+		Signature:      primary.sign(t, account.LinkMessage(linked.address, nonce)),
+		ProofSignature: linked.sign(t, account.ProofMessage(primary.address, nonce)),
+		LinkedBy:       primary.address,
+		LinkedByChain:  chain.Solana,
+		LinkNonce:      nonce,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// serverSign - signs the document like GetAttestation does and returns the keys to check it with
+func serverSign(t *testing.T, doc *attestation.Document) accesstoken.JWKS {
+	accesstoken.SetKeyring(accesstoken.NewEphemeralKeyring())
+	canonical, err := doc.Canonical()
+	assert.NoError(t, err)
+	doc.ServerSignature, err = accesstoken.SignDetached(canonical)
+	assert.NoError(t, err)
+	return accesstoken.PublicKeys()
+}
+
+func TestVerifyAttestation(t *testing.T) {
+	primary, linked, other := newSolanaKey(t), newSolanaKey(t), newSolanaKey(t)
+	accounts := []account.Account{
+		{Address: primary.address, Chain: chain.Solana, IsPrimary: true, CreatedAt: time.Now()},
+		linkedAccount(t, primary, linked),
+	}
+	doc := attestation.New(1, "shogun", accounts)
+	keys := serverSign(t, doc)
+
+	proven, err := VerifyAttestation(doc, keys)
+	assert.NoError(t, err)
+	assert.Len(t, proven, 2)
+	assert.True(t, VerifyAttestationFor(doc, keys, linked.address, chain.Solana))
+	assert.True(t, VerifyAttestationFor(doc, keys, primary.address, chain.Solana))
+	assert.False(t, VerifyAttestationFor(doc, keys, other.address, chain.Solana))
+}
+
+func TestVerifyAttestation_ServerSignature(t *testing.T) {
+	primary, linked := newSolanaKey(t), newSolanaKey(t)
+	accounts := []account.Account{
+		{Address: primary.address, Chain: chain.Solana, IsPrimary: true},
+		linkedAccount(t, primary, linked),
+	}
+	doc := attestation.New(1, "shogun", accounts)
+	keys := serverSign(t, doc)
+
+	//the wallet signatures still verify under another name, the server signature doesn't
+	doc.Username = "impostor"
+	_, err := VerifyAttestation(doc, keys)
+	assert.ErrorIs(t, err, ErrorAttestationServer)
+	assert.False(t, VerifyAttestationFor(doc, keys, linked.address, chain.Solana))
+
+	doc = attestation.New(1, "shogun", accounts)
+	serverSign(t, doc)
+	doc.UserID = 2
+	_, err = VerifyAttestation(doc, keys)
+	assert.ErrorIs(t, err, ErrorAttestationServer)
+
+	//not signed at all
+	doc = attestation.New(1, "shogun", accounts)
+	_, err = VerifyAttestation(doc, keys)
+	assert.ErrorIs(t, err, ErrorAttestationServer)
+}
+
+func TestVerifyAttestation_SignUpKeyOnly(t *testing.T) {
+	primary := newSolanaKey(t)
+	doc := attestation.New(1, "shogun", []account.Account{
+		{Address: primary.address, Chain: chain.Solana, IsPrimary: true},
+	})
+	keys := serverSign(t, doc)
+	//no wallet signature ties the key to anything, only the server vouches for it
+	proven, err := VerifyAttestation(doc, keys)
+	assert.NoError(t, err)
+	assert.Empty(t, proven)
+	assert.True(t, VerifyAttestationFor(doc, keys, primary.address, chain.Solana))
+
+	doc.Username = "impostor"
+	assert.False(t, VerifyAttestationFor(doc, keys, primary.address, chain.Solana))
+}
+
+func TestVerifyAttestation_Tampered(t *testing.T) {
+	primary, linked, other := newSolanaKey(t), newSolanaKey(t), newSolanaKey(t)
+	accounts := []account.Account{
+		{Address: primary.address, Chain: chain.Solana, IsPrimary: true},
+		linkedAccount(t, primary, linked),
+	}
+
+	doc := attestation.New(1, "shogun", accounts)
+	keys := serverSign(t, doc)
+	doc.Version = "shogun-attestation-v1"
+	_, err := VerifyAttestation(doc, keys)
+	assert.ErrorIs(t, err, ErrorAttestationVersion)
+
+	//a different message than the one we ask users to sign
+	doc = attestation.New(1, "shogun", accounts)
+	entry := doc.Find(linked.address, chain.Solana)
+	entry.Proof.Message = "hello"
+	_, err = VerifyAttestation(doc, keys)
+	assert.ErrorIs(t, err, ErrorAttestationMessage)
+
+	//signature by someone else
+	doc = attestation.New(1, "shogun", accounts)
+	entry = doc.Find(linked.address, chain.Solana)
+	entry.Proof.Signature = other.sign(t, entry.Proof.Message)
+	_, err = VerifyAttestation(doc, keys)
+	assert.ErrorIs(t, err, ErrorAttestationInvalid)
+
+	//the account that linked it isn't in the document
+	doc = attestation.New(1, "shogun", accounts)
+	entry = doc.Find(linked.address, chain.Solana)
+	doc.Accounts = []attestation.Entry{*entry}
+	_, err = VerifyAttestation(doc, keys)
+	assert.ErrorIs(t, err, ErrorAttestationSigner)
+}
+
+func TestVerifyAttestation_LinkerUnlinked(t *testing.T) {
+	first, second, linked := newSolanaKey(t), newSolanaKey(t), newSolanaKey(t)
+	//first linked both then handed the primary to second and was unlinked
+	secondAcc := linkedAccount(t, first, second)
+	secondAcc.IsPrimary = true
+	doc := attestation.New(1, "shogun", []account.Account{secondAcc, linkedAccount(t, first, linked)})
+	keys := serverSign(t, doc)
+
+	proven, err := VerifyAttestation(doc, keys)
+	assert.NoError(t, err)
+	assert.Empty(t, proven)
+	assert.Nil(t, doc.Find(linked.address, chain.Solana).Proof)
+}
+
+func TestVerifyAttestation_LinkNonce(t *testing.T) {
+	primary, linked := newSolanaKey(t), newSolanaKey(t)
+	acc := linkedAccount(t, primary, linked)
+
+	//the same signatures presented for another link challenge
+	doc := attestation.New(1, "shogun", []account.Account{
+		{Address: primary.address, Chain: chain.Solana, IsPrimary: true}, acc,
+	})
+	keys := serverSign(t, doc)
+	entry := doc.Find(linked.address, chain.Solana)
+	entry.LinkNonce = "fresh-nonce"
+	entry.Proof.Message = account.ProofMessage(primary.address, entry.LinkNonce)
+	entry.Link.Message = account.LinkMessage(linked.address, entry.LinkNonce)
+	_, err := VerifyAttestation(doc, keys)
+	assert.ErrorIs(t, err, ErrorAttestationInvalid)
+
+	//linked before messages named a challenge, the proofs could be replayed so they aren't published
+	acc.LinkNonce = ""
+	doc = attestation.New(1, "shogun", []account.Account{
+		{Address: primary.address, Chain: chain.Solana, IsPrimary: true}, acc,
+	})
+	assert.Nil(t, doc.Find(linked.address, chain.Solana).Proof)
+}
@@ -0,0 +1,171 @@
+package signverifier
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/blake2b"
+)
+
+const suiSignatureSize = 64
+
+// intentPersonalMessage - intent scope 3 (personal message), version 0, app id 0 (sui)
+var intentPersonalMessage = []byte{3, 0, 0}
+
+var (
+	ErrorInvalidSuiSignature = errors.New("invalid sui signature")
+	ErrorUnsupportedScheme   = errors.New("unsupported signature scheme")
+)
+
+// SuiSignature - a parsed sui serialized signature, flag || signature || public key
+type SuiSignature struct {
+	Flag      SigFlag
+	Signature []byte
+	PublicKey []byte
+}
+
+// ParseSuiSignature - parses the base64 serialized signature produced by sui wallets
+func ParseSuiSignature(serialized string) (*SuiSignature, error) {
+	raw, err := base64.StdEncoding.DecodeString(serialized)
+	if err != nil {
+		return nil, ErrorInvalidSuiSignature
+	}
+	if len(raw) == 0 {
+		return nil, ErrorInvalidSuiSignature
+	}
+	flag := SigFlag(raw[0])
+	keySize, ok := publicKeySize[flag]
+	if !ok {
+		return nil, ErrorUnsupportedScheme
+	}
+	if len(raw) != 1+suiSignatureSize+keySize {
+		return nil, ErrorInvalidSuiSignature
+	}
+	return &SuiSignature{
+		Flag:      flag,
+		Signature: raw[1 : 1+suiSignatureSize],
+		PublicKey: raw[1+suiSignatureSize:],
+	}, nil
+}
+
+func (s *SuiSignature) Address() string {
+	return SuiKeyToAddress(s.Flag, s.PublicKey)
+}
+
+// SuiPersonalMessageDigest - the digest sui wallets sign for a personal message,
+// blake2b of the intent followed by the bcs encoded message bytes
+func SuiPersonalMessageDigest(message []byte) [32]byte {
+	intentMessage := make([]byte, 0, len(intentPersonalMessage)+len(message)+5)
+	intentMessage = append(intentMessage, intentPersonalMessage...)
+	intentMessage = appendUleb128(intentMessage, len(message))
+	intentMessage = append(intentMessage, message...)
+	return blake2b.Sum256(intentMessage)
+}
+
+// VerifyPersonalMessage - checks the signature is over the personal message with the embedded key
+func (s *SuiSignature) VerifyPersonalMessage(message []byte) bool {
+	digest := SuiPersonalMessageDigest(message)
+	switch s.Flag {
+	case SigFlagEd25519:
+		return ed25519.Verify(s.PublicKey, digest[:], s.Signature)
+	case SigFlagSecp256k1:
+		return verifySecp256k1(s.PublicKey, digest[:], s.Signature)
+	case SigFlagSecp256r1:
+		return verifySecp256r1(s.PublicKey, digest[:], s.Signature)
+	default:
+		return false
+	}
+}
+
+func verifySecp256k1(publicKey, digest, signature []byte) bool {
+	pk, err := secp256k1.ParsePubKey(publicKey)
+	if err != nil {
+		return false
+	}
+	var r, s secp256k1.ModNScalar
+	if overflow := r.SetByteSlice(signature[:32]); overflow || r.IsZero() {
+		return false
+	}
+	if overflow := s.SetByteSlice(signature[32:]); overflow || s.IsZero() {
+		return false
+	}
+	//sui only accepts the low s form, the high one is the same signature malleated
+	if s.IsOverHalfOrder() {
+		return false
+	}
+	hash := sha256.Sum256(digest)
+	return secpecdsa.NewSignature(&r, &s).Verify(hash[:], pk)
+}
+
+func verifySecp256r1(publicKey, digest, signature []byte) bool {
+	curve := elliptic.P256()
+	x, y := elliptic.UnmarshalCompressed(curve, publicKey)
+	if x == nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	halfOrder := new(big.Int).Rsh(curve.Params().N, 1)
+	if s.Cmp(halfOrder) > 0 {
+		return false
+	}
+	hash := sha256.Sum256(digest)
+	return ecdsa.Verify(&ecdsa.PublicKey{Curve: curve, X: x, Y: y}, hash[:], r, s)
+}
+
+func verifySui(message, address string, signature string) bool {
+	if strings.Contains(signature, ":") {
+		return verifySuiLegacy(message, address, signature)
+	}
+	sig, err := ParseSuiSignature(signature)
+	if err != nil {
+		return false
+	}
+	if sig.Address() != address {
+		return false
+	}
+	return sig.VerifyPersonalMessage([]byte(message))
+}
+
+// verifySuiLegacy - older app versions sign the raw message with the ed25519 key
+// and send base58pubkey:base58sig, kept so existing link signatures still verify
+func verifySuiLegacy(message, address string, signSplit string) bool {
+	split := strings.Split(signSplit, ":")
+	if len(split) != 2 {
+		return false
+	}
+	publicKey, err := base58.Decode(split[0])
+	if err != nil {
+		return false
+	}
+	signerAddress := SuiED25519KeyToAddress(publicKey)
+	if signerAddress != address {
+		return false
+	}
+	//we use solana since it's all just ED25519 key
+	//they are the same
+	return verifySolana(message, split[0], split[1])
+}
+
+func appendUleb128(b []byte, v int) []byte {
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
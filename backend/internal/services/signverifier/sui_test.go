@@ -0,0 +1,118 @@
+package signverifier
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"shogun/internal/model/chain"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+)
+
+func serialize(flag SigFlag, sig, pk []byte) string {
+	raw := append([]byte{byte(flag)}, sig...)
+	raw = append(raw, pk...)
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func signEd25519(t *testing.T, message string) (string, string) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	digest := SuiPersonalMessageDigest([]byte(message))
+	sig := ed25519.Sign(priv, digest[:])
+	return SuiKeyToAddress(SigFlagEd25519, pub), serialize(SigFlagEd25519, sig, pub)
+}
+
+func signSecp256k1(t *testing.T, message string) (string, string) {
+	priv, err := secp256k1.GeneratePrivateKey()
+	assert.NoError(t, err)
+	digest := SuiPersonalMessageDigest([]byte(message))
+	hash := sha256.Sum256(digest[:])
+	sig := secpecdsa.Sign(priv, hash[:])
+	r, s := sig.R(), sig.S()
+	rb, sb := r.Bytes(), s.Bytes()
+	pk := priv.PubKey().SerializeCompressed()
+	return SuiKeyToAddress(SigFlagSecp256k1, pk), serialize(SigFlagSecp256k1, append(rb[:], sb[:]...), pk)
+}
+
+func signSecp256r1(t *testing.T, message string) (string, string) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	digest := SuiPersonalMessageDigest([]byte(message))
+	hash := sha256.Sum256(digest[:])
+	r, s, err := ecdsa.Sign(rand.Reader, priv, hash[:])
+	assert.NoError(t, err)
+	n := elliptic.P256().Params().N
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s.Sub(n, s)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	pk := elliptic.MarshalCompressed(elliptic.P256(), priv.X, priv.Y)
+	return SuiKeyToAddress(SigFlagSecp256r1, pk), serialize(SigFlagSecp256r1, sig, pk)
+}
+
+func TestVerify_SuiSchemes(t *testing.T) {
+	message := "shogun.social wants you to sign in with your Sui account"
+	signers := map[string]func(*testing.T, string) (string, string){
+		"ed25519":   signEd25519,
+		"secp256k1": signSecp256k1,
+		"secp256r1": signSecp256r1,
+	}
+	for name, sign := range signers {
+		t.Run(name, func(t *testing.T) {
+			address, signature := sign(t, message)
+			assert.True(t, Verify(chain.Sui, message, address, signature))
+			assert.False(t, Verify(chain.Sui, message+"!", address, signature))
+
+			otherAddress, _ := sign(t, message)
+			assert.False(t, Verify(chain.Sui, message, otherAddress, signature))
+		})
+	}
+}
+
+func TestVerify_SuiRejectsHighS(t *testing.T) {
+	message := "hello"
+	address, signature := signSecp256k1(t, message)
+	raw, _ := base64.StdEncoding.DecodeString(signature)
+
+	var s secp256k1.ModNScalar
+	s.SetByteSlice(raw[33:65])
+	s.Negate()
+	sb := s.Bytes()
+	copy(raw[33:65], sb[:])
+
+	assert.False(t, Verify(chain.Sui, message, address, base64.StdEncoding.EncodeToString(raw)))
+}
+
+func TestVerify_SuiLegacyFormat(t *testing.T) {
+	message := "I agree to give all my money now and in the future to someone"
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	sig := ed25519.Sign(priv, []byte(message))
+	signature := base58.Encode(pub) + ":" + base58.Encode(sig)
+	address := SuiED25519KeyToAddress(pub)
+
+	assert.True(t, Verify(chain.Sui, message, address, signature))
+	assert.False(t, Verify(chain.Sui, message+"!", address, signature))
+}
+
+func TestParseSuiSignature(t *testing.T) {
+	_, err := ParseSuiSignature("not base64!")
+	assert.ErrorIs(t, err, ErrorInvalidSuiSignature)
+
+	_, err = ParseSuiSignature(base64.StdEncoding.EncodeToString(make([]byte, 10)))
+	assert.ErrorIs(t, err, ErrorInvalidSuiSignature)
+
+	_, err = ParseSuiSignature(base64.StdEncoding.EncodeToString(append([]byte{0x05}, make([]byte, 97)...)))
+	assert.ErrorIs(t, err, ErrorUnsupportedScheme)
+}
@@ -12,12 +12,26 @@ type SigFlag byte
 const (
 	SigFlagEd25519   SigFlag = 0x00
 	SigFlagSecp256k1 SigFlag = 0x01
+	SigFlagSecp256r1 SigFlag = 0x02
 )
 
-func SuiED25519KeyToAddress(pubKey []byte) string {
-	newPubkey := []byte{byte(SigFlagEd25519)}
+// publicKeySize - size of the public key in a serialized signature for each scheme,
+// secp keys are always compressed
+var publicKeySize = map[SigFlag]int{
+	SigFlagEd25519:   32,
+	SigFlagSecp256k1: 33,
+	SigFlagSecp256r1: 33,
+}
+
+// SuiKeyToAddress - sui address is the blake2b hash of the scheme flag followed by the public key
+func SuiKeyToAddress(flag SigFlag, pubKey []byte) string {
+	newPubkey := []byte{byte(flag)}
 	newPubkey = append(newPubkey, pubKey...)
 
 	addrBytes := blake2b.Sum256(newPubkey)
 	return fmt.Sprintf("0x%s", hex.EncodeToString(addrBytes[:])[:64])
 }
+
+func SuiED25519KeyToAddress(pubKey []byte) string {
+	return SuiKeyToAddress(SigFlagEd25519, pubKey)
+}
@@ -0,0 +1,8 @@
+package suspension
+
+// Checker - knows which users are suspended without a database call, auth runs it on every request
+type Checker interface {
+	IsSuspended(userID int64) bool
+	// Suspended - the user was suspended or unsuspended, shared with every server
+	Suspended(userID int64, suspended bool) error
+}
@@ -0,0 +1,81 @@
+package suspension
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dreson4/graceful/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
+)
+
+const suspendedSubject = "server.users.suspended"
+
+// Handler - keeps every suspended user id in memory, there are few of them,
+// changes are shared over nats the same way sessionrevoke shares revoked sessions
+type Handler struct {
+	natsClient *nats.Conn
+	suspended  map[int64]struct{}
+	mu         sync.RWMutex
+}
+
+// NewHandler - suspended are the users suspended when this server started
+func NewHandler(client *nats.Conn, suspended []int64) *Handler {
+	h := &Handler{
+		natsClient: client,
+		suspended:  make(map[int64]struct{}, len(suspended)),
+	}
+	for _, id := range suspended {
+		h.suspended[id] = struct{}{}
+	}
+	h.listen()
+	return h
+}
+
+func (h *Handler) listen() {
+	//messages are "+<id>" to suspend and "-<id>" to lift it
+	sub, err := h.natsClient.Subscribe(suspendedSubject, func(msg *nats.Msg) {
+		data := string(msg.Data)
+		if len(data) < 2 {
+			return
+		}
+		id, err := strconv.ParseInt(data[1:], 10, 64)
+		if err != nil {
+			return
+		}
+		h.set(id, strings.HasPrefix(data, "+"))
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to listen for suspended users on nats")
+	}
+	graceful.OnShutdown(func() {
+		_ = sub.Unsubscribe()
+	})
+}
+
+func (h *Handler) set(userID int64, suspended bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if suspended {
+		h.suspended[userID] = struct{}{}
+	} else {
+		delete(h.suspended, userID)
+	}
+}
+
+func (h *Handler) Suspended(userID int64, suspended bool) error {
+	h.set(userID, suspended)
+	sign := "-"
+	if suspended {
+		sign = "+"
+	}
+	return h.natsClient.Publish(suspendedSubject, []byte(sign+strconv.FormatInt(userID, 10)))
+}
+
+func (h *Handler) IsSuspended(userID int64) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := h.suspended[userID]
+	return ok
+}
@@ -12,6 +12,8 @@ type Store interface {
 	Run()
 	Get(address string, chain chain.Chain) (*token.Token, error)
 	Create(token *token.Token) error
+	// Override - corrects a stored token, a new logo goes through the same upload as fetched ones
+	Override(address string, chain chain.Chain, o *token.Override) (*token.Token, error)
 }
 
 type Fetcher interface {
@@ -129,6 +129,33 @@ func (s *TokenStore) Create(t *token.Token) error {
 	return nil
 }
 
+func (s *TokenStore) Override(address string, chain chain.Chain, o *token.Override) (*token.Token, error) {
+	t, err := s.getFromDB(address, chain)
+	if err != nil {
+		return nil, err
+	}
+	if o.Name != nil {
+		t.Name = *o.Name
+	}
+	if o.Symbol != nil {
+		t.Symbol = *o.Symbol
+	}
+	if o.Decimals != nil {
+		t.Decimals = *o.Decimals
+	}
+	if o.Logo != nil {
+		t.Logo = *o.Logo
+		//HandleTokenLogo picks it up and moves it to our storage
+		t.Status = token.StatusNew
+	}
+	_, err = s.db.NamedExec("UPDATE shogun.token SET name = :name, symbol = :symbol, decimals = :decimals, logo = :logo, status = :status WHERE address = :address AND chain = :chain", t)
+	if err != nil {
+		return nil, err
+	}
+	s.cachedTokens.Delete(t.Address)
+	return t, nil
+}
+
 func (s *TokenStore) HandleTokenLogo() {
 	tokens := make([]token.Token, 0)
 	err := s.db.Select(&tokens, "SELECT address, symbol, chain, logo FROM shogun.token WHERE status = $1", token.StatusNew)
@@ -43,7 +43,9 @@ func (c *LruCache) Init() {
 		if !e {
 			return
 		}
-		existingUser := reflect.ValueOf(u)
+		oldUsername := u.Username
+		//updatable fields are pointers, the cached user holds the values
+		existingUser := reflect.ValueOf(u).Elem()
 		updatableValue := reflect.ValueOf(simple)
 		updatableType := reflect.TypeOf(simple)
 		for i := 0; i < updatableValue.NumField(); i++ {
@@ -52,14 +54,38 @@ func (c *LruCache) Init() {
 			}
 			field := updatableType.Field(i)
 			existingField := existingUser.FieldByName(field.Name)
-			if existingField.IsValid() {
-				existingField.Set(updatableValue.Field(i))
+			value := updatableValue.Field(i).Elem()
+			if existingField.IsValid() && value.Type().AssignableTo(existingField.Type()) {
+				existingField.Set(value)
 			}
 		}
+		if u.Username != oldUsername {
+			c.cache.Remove(fmt.Sprintf("username:%s", oldUsername))
+			c.ignored.Delete(fmt.Sprintf("username:%s", u.Username))
+			c.cache.Add(fmt.Sprintf("username:%s", u.Username), u)
+		}
 	})
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to listen for user updates")
 	}
+	err = c.userSync.ListenAccounts(func(address user.Address) {
+		ck := fmt.Sprintf("address:%s:%s", address.Address, address.Chain)
+		c.cache.Remove(ck)
+		c.ignored.Delete(ck)
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to listen for account updates")
+	}
+	err = c.userSync.ListenDeleted(func(deleted user.Deleted) {
+		c.cache.Remove(fmt.Sprintf("id:%d", deleted.ID))
+		c.cache.Remove(fmt.Sprintf("username:%s", deleted.Username))
+		for _, a := range deleted.Addresses {
+			c.cache.Remove(fmt.Sprintf("address:%s:%s", a.Address, a.Chain))
+		}
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to listen for deleted users")
+	}
 }
 
 func (c *LruCache) GetByAddress(address string, chain chain.Chain) (*user.Simple, error) {
@@ -0,0 +1,185 @@
+package usereraser
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"shogun/internal/model/user"
+	"shogun/internal/services/accountstore"
+	"shogun/internal/services/fileuploader"
+	"shogun/internal/services/prefstore"
+	"shogun/internal/services/sessionrevoke"
+	"shogun/internal/services/sessionstore"
+	"shogun/internal/services/userinfosync"
+	"shogun/internal/services/userstore"
+	"strings"
+	"time"
+
+	"github.com/dreson4/graceful/v2"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	purgeBatch = 100
+	//thumbnails are named thu<user id><22 char shortuuid><ext>
+	thumbnailIDLength = 22
+)
+
+// Eraser - deletes users and everything they left behind, right away or after a grace period
+type Eraser struct {
+	users    userstore.Store
+	accounts accountstore.Store
+	prefs    prefstore.Store
+	files    fileuploader.Service
+	sessions sessionstore.Store
+	revoker  sessionrevoke.Checker
+	userSync userinfosync.Service
+}
+
+func New(
+	users userstore.Store,
+	accounts accountstore.Store,
+	prefs prefstore.Store,
+	files fileuploader.Service,
+	sessions sessionstore.Store,
+	revoker sessionrevoke.Checker,
+	userSync userinfosync.Service,
+) *Eraser {
+	return &Eraser{
+		users:    users,
+		accounts: accounts,
+		prefs:    prefs,
+		files:    files,
+		sessions: sessions,
+		revoker:  revoker,
+		userSync: userSync,
+	}
+}
+
+// Schedule - signs the user out everywhere and erases them after grace, zero erases now,
+// returns when the user will be erased
+func (er *Eraser) Schedule(userID int64, grace time.Duration) (time.Time, error) {
+	ids, err := er.sessions.RevokeAllForUser(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(ids) > 0 {
+		if err = er.revoker.Revoke(ids...); err != nil {
+			log.Err(err).Int64("user", userID).Msg("failed to broadcast revoked sessions")
+		}
+	}
+	if grace <= 0 {
+		return time.Now(), er.Erase(userID)
+	}
+	deleteAfter := time.Now().Add(grace)
+	return deleteAfter, er.users.ScheduleDeletion(userID, deleteAfter)
+}
+
+// Erase - removes the user, their accounts, sessions, preferences and thumbnails
+func (er *Eraser) Erase(userID int64) error {
+	return er.erase(userID, er.users.Delete)
+}
+
+// erase - nothing else is touched until the user row is gone, a failed or cancelled
+// deletion leaves the user as they were
+func (er *Eraser) erase(userID int64, deleteUser func(int64) (*user.User, error)) error {
+	accounts, err := er.accounts.GetSimpleByUserID(userID)
+	if err != nil {
+		return err
+	}
+	//accounts and sessions cascade from the user row
+	u, err := deleteUser(userID)
+	if err != nil {
+		if errors.Is(err, userstore.ErrorUserNotFound) {
+			//another server got to it first
+			return nil
+		}
+		if errors.Is(err, userstore.ErrorDeletionCancelled) {
+			log.Info().Int64("user", userID).Msg("deletion cancelled before the purge")
+			return nil
+		}
+		return err
+	}
+
+	if err = er.prefs.Delete(userID); err != nil {
+		log.Error().Err(err).Int64("user", userID).Msg("failed to delete preferences of erased user")
+	}
+
+	if err = er.deleteThumbnails(userID, u.Thumbnail.Uri); err != nil {
+		//the user row is gone, nothing will retry this
+		log.Error().Err(err).Int64("user", userID).Msg("failed to delete thumbnails of erased user")
+	}
+
+	deleted := user.Deleted{
+		ID:        userID,
+		Username:  u.Username,
+		Addresses: make([]user.Address, 0, len(accounts)),
+	}
+	for _, a := range accounts {
+		deleted.Addresses = append(deleted.Addresses, user.Address{Address: a.Address, Chain: a.Chain})
+	}
+	if err = er.userSync.Deleted(deleted); err != nil {
+		log.Err(err).Int64("user", userID).Msg("failed to broadcast erased user")
+	}
+	log.Info().Int64("user", userID).Msg("user erased")
+	return nil
+}
+
+func (er *Eraser) deleteThumbnails(userID int64, current string) error {
+	prefix := fmt.Sprintf("thu%d", userID)
+	names, err := er.files.List(prefix)
+	if err != nil {
+		return err
+	}
+	toDelete := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		//the prefix of user 12 also matches user 123, only keep exact matches
+		rest := strings.TrimSuffix(strings.TrimPrefix(name, prefix), path.Ext(name))
+		if len(rest) == thumbnailIDLength {
+			toDelete = append(toDelete, name)
+		}
+	}
+	if name := fileuploader.FileName(current); name != "" {
+		toDelete = append(toDelete, name)
+	}
+	return er.files.Delete(toDelete...)
+}
+
+// Start - erases users whose grace period ended, every interval
+func (er *Eraser) Start(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	graceful.OnShutdown(func() {
+		close(done)
+	})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				er.purgeDue()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// purgeDue - also forgets the usernames of erased users once nobody needs to be kept from taking them
+func (er *Eraser) purgeDue() {
+	if n, err := er.users.PurgeUsernameHistory(); err != nil {
+		log.Err(err).Msg("failed to purge username history")
+	} else if n > 0 {
+		log.Info().Int64("count", n).Msg("purged usernames of erased users")
+	}
+	ids, err := er.users.GetDueDeletions(purgeBatch)
+	if err != nil {
+		log.Err(err).Msg("failed to get users due for deletion")
+		return
+	}
+	for _, id := range ids {
+		if err = er.erase(id, er.users.DeleteIfDue); err != nil {
+			log.Err(err).Int64("user", id).Msg("failed to erase user")
+		}
+	}
+}
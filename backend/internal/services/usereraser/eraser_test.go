@@ -0,0 +1,81 @@
+package usereraser
+
+import (
+	"shogun/internal/model/account"
+	"shogun/internal/model/user"
+	"shogun/internal/services/accountstore"
+	"shogun/internal/services/fileuploader"
+	"shogun/internal/services/prefstore"
+	"shogun/internal/services/userinfosync"
+	"shogun/internal/services/userstore"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUsers struct {
+	userstore.Store
+	due     map[int64]bool
+	deleted []int64
+	purged  bool
+}
+
+func (f *fakeUsers) PurgeUsernameHistory() (int64, error) {
+	f.purged = true
+	return 0, nil
+}
+
+func (f *fakeUsers) DeleteIfDue(id int64) (*user.User, error) {
+	if !f.due[id] {
+		return nil, userstore.ErrorDeletionCancelled
+	}
+	f.deleted = append(f.deleted, id)
+	return &user.User{ID: id}, nil
+}
+
+func (f *fakeUsers) GetDueDeletions(int) ([]int64, error) {
+	return []int64{1, 2}, nil
+}
+
+type fakeAccounts struct {
+	accountstore.Store
+}
+
+func (fakeAccounts) GetSimpleByUserID(int64) ([]account.Simple, error) {
+	return nil, nil
+}
+
+type fakePrefs struct {
+	prefstore.Store
+	deleted []int64
+}
+
+func (f *fakePrefs) Delete(userID int64) error {
+	f.deleted = append(f.deleted, userID)
+	return nil
+}
+
+type fakeFiles struct {
+	fileuploader.Service
+}
+
+func (fakeFiles) List(string) ([]string, error) { return nil, nil }
+func (fakeFiles) Delete(...string) error        { return nil }
+
+type fakeSync struct {
+	userinfosync.Service
+}
+
+func (fakeSync) Deleted(user.Deleted) error { return nil }
+
+func TestPurgeDue_SkipsCancelled(t *testing.T) {
+	//user 2 logged in and cancelled after being picked for the purge
+	users := &fakeUsers{due: map[int64]bool{1: true}}
+	prefs := &fakePrefs{}
+	er := New(users, fakeAccounts{}, prefs, fakeFiles{}, nil, nil, fakeSync{})
+
+	er.purgeDue()
+	assert.Equal(t, []int64{1}, users.deleted)
+	assert.Equal(t, []int64{1}, prefs.deleted, "the cancelled user keeps their preferences")
+	assert.True(t, users.purged)
+}
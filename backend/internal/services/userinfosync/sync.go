@@ -12,12 +12,20 @@ import (
 )
 
 const (
-	syncSubject = "user.update.sync"
+	syncSubject        = "user.update.sync"
+	accountSyncSubject = "user.account.sync"
+	deleteSyncSubject  = "user.delete.sync"
 )
 
 type Service interface {
 	Update(userID int64, simple user.Updatable) error
 	Listen(callback func(int64, user.Updatable)) error
+	// AccountChanged - the owner of the address changed, it was linked, unlinked or became primary
+	AccountChanged(address user.Address) error
+	ListenAccounts(callback func(user.Address)) error
+	// Deleted - the user was erased, every server drops what it knows about them
+	Deleted(deleted user.Deleted) error
+	ListenDeleted(callback func(user.Deleted)) error
 }
 
 type Nats struct {
@@ -61,3 +69,53 @@ func (n *Nats) Listen(callback func(int64, user.Updatable)) error {
 	})
 	return nil
 }
+
+func (n *Nats) AccountChanged(address user.Address) error {
+	a, err := json.Marshal(address)
+	if err != nil {
+		return err
+	}
+	return n.conn.Publish(accountSyncSubject, a)
+}
+
+func (n *Nats) ListenAccounts(callback func(user.Address)) error {
+	sub, err := n.conn.Subscribe(accountSyncSubject, func(msg *nats.Msg) {
+		var a user.Address
+		if err := json.Unmarshal(msg.Data, &a); err != nil {
+			return
+		}
+		callback(a)
+	})
+	if err != nil {
+		return err
+	}
+	graceful.OnShutdown(func() {
+		_ = sub.Unsubscribe()
+	})
+	return nil
+}
+
+func (n *Nats) Deleted(deleted user.Deleted) error {
+	d, err := json.Marshal(deleted)
+	if err != nil {
+		return err
+	}
+	return n.conn.Publish(deleteSyncSubject, d)
+}
+
+func (n *Nats) ListenDeleted(callback func(user.Deleted)) error {
+	sub, err := n.conn.Subscribe(deleteSyncSubject, func(msg *nats.Msg) {
+		var d user.Deleted
+		if err := json.Unmarshal(msg.Data, &d); err != nil {
+			return
+		}
+		callback(d)
+	})
+	if err != nil {
+		return err
+	}
+	graceful.OnShutdown(func() {
+		_ = sub.Unsubscribe()
+	})
+	return nil
+}
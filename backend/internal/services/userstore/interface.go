@@ -5,6 +5,7 @@ import (
 	"shogun/internal/model/chain"
 	"shogun/internal/model/image"
 	"shogun/internal/model/user"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -14,17 +15,52 @@ var (
 	ErrorDuplicateUsername                = errors.New("duplicate username")
 	ErrorUpdateNameBlockedTemporarily     = errors.New("update name blocked temporarily")
 	ErrorUpdateUsernameBlockedTemporarily = errors.New("update username blocked temporarily")
+	ErrorUsernameReserved                 = errors.New("username is reserved")
+	ErrorUsernameQuarantined              = errors.New("username was released recently, only its previous owner can take it")
+	ErrorReservedWordNotFound             = errors.New("reserved word not found")
+	ErrorDeletionCancelled                = errors.New("deletion was cancelled")
 )
 
 type Store interface {
 	CreateUserNoCommit(tx *sqlx.Tx, u *user.User) error
 	GetOne(id int64) (*user.User, error)
 	GetMeta(id int64) (*user.Meta, error)
+	// Update - a new username must not be reserved or in another user's quarantine,
+	// the username it replaces goes into quarantine
 	Update(id int64, updatable *user.Updatable) error
 	UpdateThumbnail(id int64, thumbnail *image.Image) error
 	GetSimpleOwnerOfAddress(address string, chain chain.Chain) (*user.Simple, error)
 	GetSimpleOwnersOfAddresses(addresses []string, chain chain.Chain) ([]user.SimpleByAddress, error)
 	GetSimpleByID(id int64) (*user.Simple, error)
 	GetSimpleByUsername(username string) (*user.Simple, error)
-	GetAllUsernames(func(username string)) error
+	// Search - users whose username or name is close to query, most relevant first,
+	// users the searcher has transacted with rank higher, search preferences are left to visibility.Policy
+	Search(query string, searcherID int64, offset, limit int) ([]user.SearchResult, error)
+	// GetAllUsernames - walks every user in id order, for indexes kept in memory
+	GetAllUsernames(func(userID int64, username string)) error
+	// ScheduleDeletion - marks the user to be erased after the given time
+	ScheduleDeletion(id int64, after time.Time) error
+	// CancelDeletion - clears a scheduled deletion, returns true if one was pending
+	CancelDeletion(id int64) (bool, error)
+	GetDueDeletions(limit int) ([]int64, error)
+	// Delete - removes the user row, accounts and sessions go with it, the username stays quarantined,
+	// returns the removed user
+	Delete(id int64) (*user.User, error)
+	// DeleteIfDue - Delete for the purge, ErrorDeletionCancelled when delete_after was cleared or
+	// moved since the user was picked, the check and the delete share the row lock with CancelDeletion
+	DeleteIfDue(id int64) (*user.User, error)
+	Suspend(id int64, reason string) error
+	Unsuspend(id int64) error
+	GetSuspendedIDs() ([]int64, error)
+	// ResetUsernameLock - lets the user change their username again right away
+	ResetUsernameLock(id int64) error
+	// GetUsernameHistory - usernames the user gave up, newest first
+	GetUsernameHistory(id int64) ([]user.PastUsername, error)
+	// PurgeUsernameHistory - forgets the usernames of deleted users once their quarantine ended,
+	// returns how many were removed
+	PurgeUsernameHistory() (int64, error)
+	GetReservedWords() ([]user.ReservedWord, error)
+	// AddReservedWord - adds the word or replaces how it matches
+	AddReservedWord(w *user.ReservedWord) error
+	RemoveReservedWord(word string) error
 }
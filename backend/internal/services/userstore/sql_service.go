@@ -9,6 +9,7 @@ import (
 	"shogun/internal/model/chain"
 	"shogun/internal/model/image"
 	"shogun/internal/model/user"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,11 +18,14 @@ import (
 
 type SqlStore struct {
 	db *sqlx.DB
+	//usernameQuarantine - how long only the previous owner can take a released username
+	usernameQuarantine time.Duration
 }
 
-func NewSqlStore(db *sqlx.DB) *SqlStore {
+func NewSqlStore(db *sqlx.DB, usernameQuarantine time.Duration) *SqlStore {
 	return &SqlStore{
-		db: db,
+		db:                 db,
+		usernameQuarantine: usernameQuarantine,
 	}
 }
 
@@ -108,18 +112,148 @@ func (sus *SqlStore) Update(id int64, updatable *user.Updatable) error {
 		values["meta"] = updateMeta
 	}
 
+	tx, err := sus.db.Beginx()
+	if err != nil {
+		return err
+	}
+	if updatable.Username != nil {
+		if err = sus.releaseUsername(tx, id, *updatable.Username); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
 	values["id"] = id
 	query := fmt.Sprintf("UPDATE shogun.user SET %s WHERE id = :id", strings.Join(fields, ", "))
-	_, err := sus.db.NamedExec(query, values)
+	_, err = tx.NamedExec(query, values)
 	if err != nil {
+		_ = tx.Rollback()
 		if data.IsUniqueViolation(err) {
 			return ErrorDuplicateUsername
 		}
 		return err
 	}
+	return tx.Commit()
+}
+
+// usernameLockClass - first key of the two key advisory locks on usernames, keeps them apart from other locks
+const usernameLockClass = 1
+
+// lockUsernames - holds every username until the transaction ends, a rename taking a username waits
+// for the rename or delete giving it up to commit, so the quarantine check sees its history row
+// locks are taken in the same order everywhere so two swaps can't deadlock
+func lockUsernames(tx *sqlx.Tx, usernames ...string) error {
+	keys := make([]string, 0, len(usernames))
+	for _, u := range usernames {
+		if u != "" {
+			keys = append(keys, strings.ToLower(u))
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range slices.Compact(keys) {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1, hashtext($2))", usernameLockClass, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseUsername - checks the user may take username and puts their current one in quarantine,
+// the user row stays locked until the transaction ends so two renames can't race
+func (sus *SqlStore) releaseUsername(tx *sqlx.Tx, id int64, username string) error {
+	var current sql.NullString
+	err := tx.Get(&current, "SELECT username FROM shogun.user WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrorUserNotFound
+		}
+		return err
+	}
+	if strings.EqualFold(current.String, username) {
+		return nil
+	}
+	if err = lockUsernames(tx, username, current.String); err != nil {
+		return err
+	}
+	words := make([]user.ReservedWord, 0)
+	err = tx.Select(&words, "SELECT * FROM shogun.reserved_username WHERE word = $1 OR match_type = $2", username, user.ReservedContains)
+	if err != nil {
+		return err
+	}
+	for _, w := range words {
+		if w.Blocks(username) {
+			return ErrorUsernameReserved
+		}
+	}
+	var quarantined bool
+	err = tx.Get(&quarantined, `SELECT EXISTS (SELECT 1 FROM shogun.username_history
+		WHERE username = $1 AND user_id IS DISTINCT FROM $2 AND released_at > $3)`, username, id, time.Now().Add(-sus.usernameQuarantine))
+	if err != nil {
+		return err
+	}
+	if quarantined {
+		return ErrorUsernameQuarantined
+	}
+	if current.String == "" {
+		return nil
+	}
+	_, err = tx.Exec("INSERT INTO shogun.username_history(user_id, username) VALUES ($1, $2)", id, current.String)
 	return err
 }
 
+func (sus *SqlStore) GetUsernameHistory(id int64) ([]user.PastUsername, error) {
+	res := make([]user.PastUsername, 0)
+	err := sus.db.Select(&res, "SELECT username, released_at FROM shogun.username_history WHERE user_id = $1 ORDER BY released_at DESC", id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range res {
+		res[i].ReclaimableUntil = res[i].ReleasedAt.Add(sus.usernameQuarantine)
+	}
+	return res, nil
+}
+
+func (sus *SqlStore) PurgeUsernameHistory() (int64, error) {
+	res, err := sus.db.Exec("DELETE FROM shogun.username_history WHERE user_id IS NULL AND released_at <= $1",
+		time.Now().Add(-sus.usernameQuarantine))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (sus *SqlStore) GetReservedWords() ([]user.ReservedWord, error) {
+	res := make([]user.ReservedWord, 0)
+	err := sus.db.Select(&res, "SELECT * FROM shogun.reserved_username ORDER BY word")
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (sus *SqlStore) AddReservedWord(w *user.ReservedWord) error {
+	w.CreatedAt = time.Now()
+	_, err := sus.db.NamedExec(`INSERT INTO shogun.reserved_username(word, match_type, created_by, created_at)
+		VALUES (:word, :match_type, :created_by, :created_at)
+		ON CONFLICT (word) DO UPDATE SET match_type = EXCLUDED.match_type, created_by = EXCLUDED.created_by, created_at = EXCLUDED.created_at`, w)
+	return err
+}
+
+func (sus *SqlStore) RemoveReservedWord(word string) error {
+	res, err := sus.db.Exec("DELETE FROM shogun.reserved_username WHERE word = $1", word)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrorReservedWordNotFound
+	}
+	return nil
+}
+
 func (sus *SqlStore) UpdateThumbnail(id int64, location *image.Image) error {
 	_, err := sus.db.Exec("UPDATE shogun.user SET thumbnail = $1 WHERE id = $2", location, id)
 	return err
@@ -170,6 +304,40 @@ func (sus *SqlStore) GetSimpleByUsername(username string) (*user.Simple, error)
 	return u, nil
 }
 
+// searchInteractionBoost - added to the relevance of users the searcher has transacted with,
+// enough to put a decent match above a slightly better one from a stranger
+const searchInteractionBoost = 0.3
+
+// searchPrefixScore - relevance of a username starting with the query, too short for trigrams to match well
+const searchPrefixScore = 0.9
+
+func (sus *SqlStore) Search(query string, searcherID int64, offset, limit int) ([]user.SearchResult, error) {
+	res := make([]user.SearchResult, 0)
+	prefix := escapeLike(query) + "%"
+	err := sus.db.Select(&res, `SELECT u.id, u.username, u.name, u.thumbnail,
+			i.user_id IS NOT NULL AS interacted,
+			GREATEST(
+				similarity(u.username::TEXT, $1),
+				word_similarity($1, u.name::TEXT),
+				CASE WHEN u.username::TEXT ILIKE $2 THEN $3::REAL ELSE 0 END
+			) + CASE WHEN i.user_id IS NULL THEN 0 ELSE $4::REAL END AS score
+		FROM shogun.user u
+		LEFT JOIN shogun.user_interaction i ON i.user_id = $5 AND i.other_id = u.id
+		WHERE (u.username::TEXT % $1 OR $1 <% u.name::TEXT OR u.username::TEXT ILIKE $2)
+		ORDER BY score DESC, u.id
+		LIMIT $6 OFFSET $7`,
+		query, prefix, searchPrefixScore, searchInteractionBoost, searcherID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// escapeLike - underscores are valid in usernames, they must not match any character
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 func (sus *SqlStore) GetUsernames(limit int) ([]string, error) {
 	usernames := make([]string, 0)
 	offset := 0
@@ -190,7 +358,7 @@ func (sus *SqlStore) GetUsernames(limit int) ([]string, error) {
 	return usernames, nil
 }
 
-func (sus *SqlStore) GetAllUsernames(callback func(username string)) error {
+func (sus *SqlStore) GetAllUsernames(callback func(userID int64, username string)) error {
 	limit := 5000
 	lastID := int64(0)
 	for {
@@ -205,8 +373,116 @@ func (sus *SqlStore) GetAllUsernames(callback func(username string)) error {
 		}
 		lastID = us[len(us)-1].ID
 		for _, u := range us {
-			callback(u.Username)
+			callback(u.ID, u.Username)
+		}
+	}
+	return nil
+}
+
+func (sus *SqlStore) ScheduleDeletion(id int64, after time.Time) error {
+	return sus.execOnUser("UPDATE shogun.user SET delete_after = $1 WHERE id = $2", after, id)
+}
+
+func (sus *SqlStore) CancelDeletion(id int64) (bool, error) {
+	res, err := sus.db.Exec("UPDATE shogun.user SET delete_after = NULL WHERE id = $1 AND delete_after IS NOT NULL", id)
+	if err != nil {
+		return false, err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (sus *SqlStore) GetDueDeletions(limit int) ([]int64, error) {
+	ids := make([]int64, 0)
+	err := sus.db.Select(&ids, "SELECT id FROM shogun.user WHERE delete_after <= NOW() ORDER BY delete_after LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Delete - the username goes into quarantine like a rename would, its history row and the older ones
+// stay behind without a user so the handle can't be taken by someone posing as the deleted user,
+// PurgeUsernameHistory removes them once the quarantine ended
+func (sus *SqlStore) Delete(id int64) (*user.User, error) {
+	return sus.delete(id, false)
+}
+
+func (sus *SqlStore) DeleteIfDue(id int64) (*user.User, error) {
+	return sus.delete(id, true)
+}
+
+func (sus *SqlStore) delete(id int64, onlyDue bool) (*user.User, error) {
+	tx, err := sus.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	current := struct {
+		Username sql.NullString `db:"username"`
+		Due      bool           `db:"due"`
+	}{}
+	err = tx.Get(&current, `SELECT username, (delete_after IS NOT NULL AND delete_after <= NOW()) AS due
+		FROM shogun.user WHERE id = $1 FOR UPDATE`, id)
+	if err == nil && onlyDue && !current.Due {
+		_ = tx.Rollback()
+		return nil, ErrorDeletionCancelled
+	}
+	if err == nil {
+		err = lockUsernames(tx, current.Username.String)
+	}
+	u := user.New()
+	if err == nil {
+		err = tx.Get(u, "DELETE FROM shogun.user WHERE id = $1 RETURNING *", id)
+	}
+	if err == nil && current.Username.String != "" {
+		_, err = tx.Exec("INSERT INTO shogun.username_history(user_id, username) VALUES (NULL, $1)", current.Username.String)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrorUserNotFound
 		}
+		return nil, err
+	}
+	return u, tx.Commit()
+}
+
+func (sus *SqlStore) Suspend(id int64, reason string) error {
+	return sus.execOnUser("UPDATE shogun.user SET suspended_at = NOW(), suspended_reason = $2 WHERE id = $1", id, reason)
+}
+
+func (sus *SqlStore) Unsuspend(id int64) error {
+	return sus.execOnUser("UPDATE shogun.user SET suspended_at = NULL, suspended_reason = '' WHERE id = $1", id)
+}
+
+func (sus *SqlStore) GetSuspendedIDs() ([]int64, error) {
+	ids := make([]int64, 0)
+	err := sus.db.Select(&ids, "SELECT id FROM shogun.user WHERE suspended_at IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (sus *SqlStore) ResetUsernameLock(id int64) error {
+	return sus.execOnUser("UPDATE shogun.user SET meta = meta - 'last_username_update' WHERE id = $1", id)
+}
+
+// execOnUser - runs an update on one user, ErrorUserNotFound if there's no such user
+func (sus *SqlStore) execOnUser(query string, args ...any) error {
+	res, err := sus.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrorUserNotFound
 	}
 	return nil
 }
@@ -0,0 +1,86 @@
+package visibility
+
+import (
+	"shogun/internal/model/preferences"
+	"shogun/internal/services/prefstore"
+)
+
+// Rule - the preference that lets others find a user through one kind of lookup
+type Rule func(preferences.Preferences) bool
+
+var (
+	// ByUsername - lookups by username or name
+	ByUsername Rule = preferences.Preferences.UsernameSearchable
+	// ByAddress - lookups that tie an address to the user, including history enrichment
+	ByAddress Rule = preferences.Preferences.AddressSearchable
+)
+
+// Contacts - contacts find each other whatever their preferences say
+type Contacts interface {
+	// ContactsAmong - which of otherIDs are contacts of userID
+	ContactsAmong(userID int64, otherIDs []int64) (map[int64]bool, error)
+}
+
+// NoContacts - nobody is a contact of anybody
+type NoContacts struct{}
+
+func (NoContacts) ContactsAmong(int64, []int64) (map[int64]bool, error) {
+	return map[int64]bool{}, nil
+}
+
+// Policy - decides who a viewer may find, every user lookup on behalf of someone else goes through it,
+// users always see themselves, viewerID is 0 when nobody is logged in
+type Policy struct {
+	prefs    prefstore.Store
+	contacts Contacts
+}
+
+func New(prefs prefstore.Store, contacts Contacts) *Policy {
+	return &Policy{
+		prefs:    prefs,
+		contacts: contacts,
+	}
+}
+
+// Allows - whether viewerID may find targetID through the lookup
+func (p *Policy) Allows(viewerID, targetID int64, rule Rule) (bool, error) {
+	visible, err := p.Filter(viewerID, []int64{targetID}, rule)
+	if err != nil {
+		return false, err
+	}
+	return visible[targetID], nil
+}
+
+// Filter - which of targetIDs viewerID may find through the lookup
+func (p *Policy) Filter(viewerID int64, targetIDs []int64, rule Rule) (map[int64]bool, error) {
+	visible := make(map[int64]bool, len(targetIDs))
+	if len(targetIDs) == 0 {
+		return visible, nil
+	}
+	prefs, err := p.prefs.GetMany(targetIDs)
+	if err != nil {
+		return nil, err
+	}
+	hidden := make([]int64, 0)
+	for _, id := range targetIDs {
+		//users without preferences have the defaults, everything is on
+		if pref, ok := prefs[id]; ok && !rule(pref) && id != viewerID {
+			hidden = append(hidden, id)
+			continue
+		}
+		visible[id] = true
+	}
+	if len(hidden) == 0 || viewerID == 0 {
+		return visible, nil
+	}
+	contacts, err := p.contacts.ContactsAmong(viewerID, hidden)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range hidden {
+		if contacts[id] {
+			visible[id] = true
+		}
+	}
+	return visible, nil
+}
@@ -0,0 +1,42 @@
+package visibility
+
+import (
+	"shogun/internal/model/preferences"
+	"shogun/internal/services/visibility/visibilitytest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Filter(t *testing.T) {
+	on, off := true, false
+	prefs := visibilitytest.Prefs{Of: map[int64]preferences.Preferences{
+		1: {SearchUsername: &off, SearchAddress: &on},
+		2: {SearchUsername: &on, SearchAddress: &off},
+		3: {SearchUsername: &off, SearchAddress: &off},
+		//4 has no preferences saved, the defaults let everyone find them
+	}}
+	contacts := &visibilitytest.Contacts{Of: map[int64][]int64{10: {3}}}
+	p := New(prefs, contacts)
+	all := []int64{1, 2, 3, 4}
+
+	visible, err := p.Filter(10, all, ByUsername)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]bool{2: true, 3: true, 4: true}, visible)
+
+	visible, err = p.Filter(10, all, ByAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]bool{1: true, 3: true, 4: true}, visible)
+
+	//users always find themselves
+	ok, err := p.Allows(2, 2, ByAddress)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	//nobody logged in has no contacts
+	contacts.Calls = 0
+	visible, err = p.Filter(0, all, ByAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]bool{1: true, 4: true}, visible)
+	assert.Zero(t, contacts.Calls)
+}
@@ -0,0 +1,41 @@
+package visibilitytest
+
+import (
+	"shogun/internal/model/preferences"
+	"shogun/internal/services/prefstore"
+)
+
+// Prefs - saved preferences for visibility.New, users missing from the map have none saved
+type Prefs struct {
+	prefstore.Store
+	Of map[int64]preferences.Preferences
+}
+
+func (f Prefs) GetMany(userIDs []int64) (map[int64]preferences.Preferences, error) {
+	res := make(map[int64]preferences.Preferences)
+	for _, id := range userIDs {
+		if p, ok := f.Of[id]; ok {
+			res[id] = p
+		}
+	}
+	return res, nil
+}
+
+// Contacts - the contacts of each user for visibility.New, Calls counts the lookups
+type Contacts struct {
+	Of    map[int64][]int64
+	Calls int
+}
+
+func (f *Contacts) ContactsAmong(userID int64, otherIDs []int64) (map[int64]bool, error) {
+	f.Calls++
+	res := make(map[int64]bool)
+	for _, c := range f.Of[userID] {
+		for _, id := range otherIDs {
+			if c == id {
+				res[id] = true
+			}
+		}
+	}
+	return res, nil
+}
@@ -3,6 +3,8 @@ package walletstore
 import (
 	"context"
 	"shogun/internal/model/chain"
+	"shogun/internal/model/risk"
+	"shogun/internal/services/screening"
 
 	"github.com/shopspring/decimal"
 )
@@ -16,6 +18,16 @@ type CoinsOwned struct {
 	NativeBalance Token           `json:"native"`
 	Tokens        []Token         `json:"tokens"`
 	USDValue      decimal.Decimal `json:"usd_value"`
+	Risk          []risk.Flag     `json:"risk,omitempty"` //flags of the wallet address itself
+}
+
+// SetRisk - flags the wallet and any token contracts that are on a risk list
+func (c *CoinsOwned) SetRisk(screener screening.Screener, address string, ch chain.Chain) {
+	c.Risk = screener.Screen(address, ch)
+	for i := range c.Tokens {
+		t := &c.Tokens[i]
+		t.Risk = screener.Screen(t.Address, ch)
+	}
 }
 
 func (c *CoinsOwned) SetUSDValue() {
@@ -42,6 +54,7 @@ type Token struct {
 	Logo    string          `json:"logo"`
 	Price   decimal.Decimal `json:"price"`
 	Total   decimal.Decimal `json:"total"`
+	Risk    []risk.Flag     `json:"risk,omitempty"`
 	Balance
 }
 
@@ -0,0 +1,23 @@
+package text
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Truncate - keeps at most maxChars characters, VARCHAR(n) counts characters not bytes,
+// invalid utf-8 and NUL bytes are dropped since postgres refuses them in text columns
+func Truncate(s string, maxChars int) string {
+	s = strings.ReplaceAll(strings.ToValidUTF8(s, ""), "\x00", "")
+	if utf8.RuneCountInString(s) <= maxChars {
+		return s
+	}
+	count := 0
+	for i := range s {
+		if count == maxChars {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
@@ -0,0 +1,23 @@
+package text
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", Truncate("abc", 3))
+	assert.Equal(t, "ab", Truncate("abc", 2))
+	assert.Equal(t, "", Truncate("abc", 0))
+
+	//characters, not bytes, and never half of one
+	assert.Equal(t, "東京", Truncate("東京タワー", 2))
+	assert.Equal(t, "a😀", Truncate("a😀😀", 2))
+	assert.Equal(t, "😀😀", Truncate("😀😀", 2))
+
+	assert.Equal(t, "ab", Truncate("a\xffb", 5))
+	assert.Equal(t, "ab", Truncate("a\x00b", 5))
+	assert.True(t, utf8.ValidString(Truncate("x\xe6\x9d", 5)))
+}
@@ -10,4 +10,24 @@ CREATE TABLE shogun.account (
     FOREIGN KEY (user_id) REFERENCES shogun.user(id) ON DELETE CASCADE ON UPDATE CASCADE
 );
 
-CREATE INDEX idx_user_id ON shogun.account(user_id);
\ No newline at end of file
+CREATE INDEX idx_user_id ON shogun.account(user_id);
+
+--- the primary account is the login key of the user, every user has exactly one
+ALTER TABLE shogun.account ADD COLUMN IF NOT EXISTS is_primary BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE shogun.account AS a SET is_primary = TRUE
+WHERE a.id = (SELECT MIN(id) FROM shogun.account WHERE user_id = a.user_id)
+  AND NOT EXISTS (SELECT 1 FROM shogun.account WHERE user_id = a.user_id AND is_primary);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_primary ON shogun.account(user_id) WHERE is_primary;
+
+--- proof of the link, kept so anyone can verify the account belongs to the user
+--- linked_by is the primary account at the time of linking, it signed the link message and is
+--- the address in the proof message, empty for the sign up key and accounts linked before this
+ALTER TABLE shogun.account ADD COLUMN IF NOT EXISTS proof_signature TEXT NOT NULL DEFAULT '';
+ALTER TABLE shogun.account ADD COLUMN IF NOT EXISTS linked_by VARCHAR(80) NOT NULL DEFAULT '';
+ALTER TABLE shogun.account ADD COLUMN IF NOT EXISTS linked_by_chain VARCHAR(10) NOT NULL DEFAULT '';
+
+--- link messages are bound to the link challenge, old proofs can't be replayed to link an address again
+--- rows linked before this have an empty nonce and their proofs aren't published
+ALTER TABLE shogun.account ADD COLUMN IF NOT EXISTS link_nonce VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,25 @@
+CREATE TABLE shogun.api_key (
+    id BIGINT NOT NULL DEFAULT shogun.next_id() PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(64) NOT NULL DEFAULT '',
+    prefix VARCHAR(16) NOT NULL,
+    secret_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes JSONB NOT NULL DEFAULT '[]',
+    hourly_quota BIGINT NOT NULL,
+    created_by BIGINT NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES shogun.user(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_api_key_user_id ON shogun.api_key(user_id);
+
+--- requests per key per day, written in batches
+CREATE TABLE shogun.api_key_usage (
+    key_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day),
+    FOREIGN KEY (key_id) REFERENCES shogun.api_key(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
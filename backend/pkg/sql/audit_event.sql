@@ -0,0 +1,27 @@
+CREATE TABLE shogun.audit_event (
+    id BIGINT NOT NULL DEFAULT shogun.next_id() PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    actor_id BIGINT NOT NULL DEFAULT 0,
+    action VARCHAR(32) NOT NULL,
+    target VARCHAR(128) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent VARCHAR(256) NOT NULL DEFAULT '',
+    payload JSONB NOT NULL DEFAULT '{}'::JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_event_user_id ON shogun.audit_event(user_id, id DESC);
+CREATE INDEX idx_audit_event_actor_id ON shogun.audit_event(actor_id, id DESC);
+CREATE INDEX idx_audit_event_action ON shogun.audit_event(action, id DESC);
+
+--- append only, rows can be added but never changed or removed
+CREATE OR REPLACE FUNCTION shogun.audit_event_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_event is append only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tr_audit_event_append_only
+BEFORE UPDATE OR DELETE ON shogun.audit_event
+FOR EACH ROW
+EXECUTE FUNCTION shogun.audit_event_append_only();
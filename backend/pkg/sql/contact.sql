@@ -0,0 +1,16 @@
+--- contact requests go from user_id to contact_id, once accepted there is a row each way
+--- so the contacts of a user are always the rows with their user_id
+CREATE TABLE shogun.contact (
+    id BIGINT NOT NULL DEFAULT shogun.next_id() PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    contact_id BIGINT NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, contact_id),
+    CHECK (user_id <> contact_id),
+    FOREIGN KEY (user_id) REFERENCES shogun.user(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (contact_id) REFERENCES shogun.user(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_contact_contact_id ON shogun.contact(contact_id, status);
@@ -0,0 +1,7 @@
+CREATE UNLOGGED TABLE shogun.rate_limit (
+    key VARCHAR(128) NOT NULL PRIMARY KEY,
+    count BIGINT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_expires_at ON shogun.rate_limit(expires_at);
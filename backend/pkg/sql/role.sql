@@ -0,0 +1,19 @@
+CREATE TABLE shogun.user_role (
+    user_id BIGINT NOT NULL PRIMARY KEY,
+    role VARCHAR(16) NOT NULL,
+    granted_by BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES shogun.user(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+--- every grant and removal is kept, rows stay after the user is gone
+CREATE TABLE shogun.user_role_log (
+    id BIGINT NOT NULL DEFAULT shogun.next_id() PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT '',
+    previous_role VARCHAR(16) NOT NULL DEFAULT '',
+    actor_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_role_log_user_id ON shogun.user_role_log(user_id);
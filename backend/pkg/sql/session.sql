@@ -0,0 +1,22 @@
+CREATE TABLE shogun.session (
+    id BIGINT NOT NULL DEFAULT shogun.next_id() PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    refresh_token_hash VARCHAR(64) NOT NULL UNIQUE,
+    previous_token_hash VARCHAR(64) NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES shogun.user(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_session_user_id ON shogun.session(user_id);
+CREATE INDEX idx_session_previous_token_hash ON shogun.session(previous_token_hash);
+CREATE INDEX idx_session_revoked_at ON shogun.session(revoked_at) WHERE revoked_at IS NOT NULL;
+
+--- device details so users can see where they are signed in
+ALTER TABLE shogun.session ADD COLUMN IF NOT EXISTS device_name VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE shogun.session ADD COLUMN IF NOT EXISTS platform VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE shogun.session ADD COLUMN IF NOT EXISTS ip VARCHAR(45) NOT NULL DEFAULT '';
+ALTER TABLE shogun.session ADD COLUMN IF NOT EXISTS user_agent VARCHAR(256) NOT NULL DEFAULT '';
+ALTER TABLE shogun.session ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
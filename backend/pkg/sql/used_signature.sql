@@ -0,0 +1,7 @@
+CREATE TABLE shogun.used_signature (
+    hash VARCHAR(64) NOT NULL PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_used_signature_expires_at ON shogun.used_signature(expires_at);
@@ -7,4 +7,39 @@ CREATE TABLE shogun.user (
     meta JSONB NOT NULL DEFAULT '{}'::JSONB,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
\ No newline at end of file
+);
+
+--- account deletion, the user is erased once delete_after has passed
+ALTER TABLE shogun.user ADD COLUMN IF NOT EXISTS delete_after TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_user_delete_after ON shogun.user(delete_after) WHERE delete_after IS NOT NULL;
+
+--- suspended users can't log in and their tokens are rejected
+ALTER TABLE shogun.user ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMPTZ;
+ALTER TABLE shogun.user ADD COLUMN IF NOT EXISTS suspended_reason TEXT NOT NULL DEFAULT '';
+
+--- fuzzy search on username and display name, citext has no trigram operator class so the text is indexed
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_user_username_trgm ON shogun.user USING GIN ((username::TEXT) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_user_name_trgm ON shogun.user USING GIN ((name::TEXT) gin_trgm_ops);
+
+--- usernames users gave up, only the previous owner can take one back until its quarantine ends
+--- rows outlive deleted users with user_id NULL, so nobody can take their handle right away,
+--- the eraser removes them once the quarantine ended
+CREATE TABLE IF NOT EXISTS shogun.username_history (
+    id BIGINT NOT NULL DEFAULT shogun.next_id() PRIMARY KEY,
+    user_id BIGINT,
+    username CITEXT NOT NULL,
+    released_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES shogun.user(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_username_history_username ON shogun.username_history(username, released_at);
+CREATE INDEX IF NOT EXISTS idx_username_history_user_id ON shogun.username_history(user_id);
+
+--- words nobody can take as a username, managed by admins
+CREATE TABLE IF NOT EXISTS shogun.reserved_username (
+    word CITEXT NOT NULL PRIMARY KEY,
+    match_type VARCHAR(10) NOT NULL DEFAULT 'exact',
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
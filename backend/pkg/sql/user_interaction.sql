@@ -0,0 +1,11 @@
+--- users who sent to or received from each other, kept both ways so lookups only need user_id
+CREATE TABLE shogun.user_interaction (
+    user_id BIGINT NOT NULL,
+    other_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, other_id),
+    FOREIGN KEY (user_id) REFERENCES shogun.user(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (other_id) REFERENCES shogun.user(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_user_interaction_other_id ON shogun.user_interaction(other_id);